@@ -0,0 +1,1596 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: presence/v1/presence.proto
+
+package presencev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// State is a snapshot of the detected presence.
+type State struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Present bool                   `protobuf:"varint,1,opt,name=present,proto3" json:"present,omitempty"`
+	// Since is when the household state last changed. It is unset when
+	// unknown.
+	Since    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=since,proto3" json:"since,omitempty"`
+	Flapping bool                   `protobuf:"varint,3,opt,name=flapping,proto3" json:"flapping,omitempty"`
+	// Suppressed counts the triggers of each IFTTT event held back by its
+	// rate limit.
+	Suppressed map[string]uint32 `protobuf:"bytes,4,rep,name=suppressed,proto3" json:"suppressed,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Devices    []*Device         `protobuf:"bytes,5,rep,name=devices,proto3" json:"devices,omitempty"`
+	// GuestMode is whether guest devices counted toward the household.
+	GuestMode bool `protobuf:"varint,6,opt,name=guest_mode,json=guestMode,proto3" json:"guest_mode,omitempty"`
+	// Zone is the name of the zone, or empty for the household of the config,
+	// whose state has the states of the zones in zones.
+	Zone          string   `protobuf:"bytes,7,opt,name=zone,proto3" json:"zone,omitempty"`
+	Zones         []*State `protobuf:"bytes,8,rep,name=zones,proto3" json:"zones,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_presence_v1_presence_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *State) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *State) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *State) GetFlapping() bool {
+	if x != nil {
+		return x.Flapping
+	}
+	return false
+}
+
+func (x *State) GetSuppressed() map[string]uint32 {
+	if x != nil {
+		return x.Suppressed
+	}
+	return nil
+}
+
+func (x *State) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+func (x *State) GetGuestMode() bool {
+	if x != nil {
+		return x.GuestMode
+	}
+	return false
+}
+
+func (x *State) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *State) GetZones() []*State {
+	if x != nil {
+		return x.Zones
+	}
+	return nil
+}
+
+// Device is the detected presence of a device.
+type Device struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	MacAddress string                 `protobuf:"bytes,1,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	Name       string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Owner      string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Type       string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Icon       string                 `protobuf:"bytes,5,opt,name=icon,proto3" json:"icon,omitempty"`
+	Tags       []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	Present    bool                   `protobuf:"varint,7,opt,name=present,proto3" json:"present,omitempty"`
+	Interface  string                 `protobuf:"bytes,8,opt,name=interface,proto3" json:"interface,omitempty"`
+	// Since is when the device's state was first detected or last changed.
+	// It is unset when unknown.
+	Since    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=since,proto3" json:"since,omitempty"`
+	Flapping bool                   `protobuf:"varint,10,opt,name=flapping,proto3" json:"flapping,omitempty"`
+	// LastSeen is when the device was last detected present. It is unset when
+	// it has not been.
+	LastSeen *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	// Override is the override of the device's state. It is unset when its
+	// state is as detected.
+	Override *Override `protobuf:"bytes,12,opt,name=override,proto3" json:"override,omitempty"`
+	// Role is how the device counts toward the household (resident, guest or
+	// ignored).
+	Role          string `protobuf:"bytes,13,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Device) Reset() {
+	*x = Device{}
+	mi := &file_presence_v1_presence_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Device) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Device) ProtoMessage() {}
+
+func (x *Device) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Device.ProtoReflect.Descriptor instead.
+func (*Device) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Device) GetMacAddress() string {
+	if x != nil {
+		return x.MacAddress
+	}
+	return ""
+}
+
+func (x *Device) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Device) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Device) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Device) GetIcon() string {
+	if x != nil {
+		return x.Icon
+	}
+	return ""
+}
+
+func (x *Device) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Device) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *Device) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *Device) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *Device) GetFlapping() bool {
+	if x != nil {
+		return x.Flapping
+	}
+	return false
+}
+
+func (x *Device) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *Device) GetOverride() *Override {
+	if x != nil {
+		return x.Override
+	}
+	return nil
+}
+
+func (x *Device) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+// Override pins the presence of a device or person.
+type Override struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Target is the MAC address or name of a device, or the owner of devices.
+	Target  string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Present bool   `protobuf:"varint,2,opt,name=present,proto3" json:"present,omitempty"`
+	// Until is when the override expires. It is unset when it does not.
+	Until         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=until,proto3" json:"until,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created,proto3" json:"created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Override) Reset() {
+	*x = Override{}
+	mi := &file_presence_v1_presence_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Override) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Override) ProtoMessage() {}
+
+func (x *Override) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Override.ProtoReflect.Descriptor instead.
+func (*Override) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Override) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Override) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *Override) GetUntil() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Until
+	}
+	return nil
+}
+
+func (x *Override) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Override) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+// Change is a change of the presence of the household or of a device.
+type Change struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// MACAddress is the device which changed. It is empty for the household.
+	MacAddress string `protobuf:"bytes,2,opt,name=mac_address,json=macAddress,proto3" json:"mac_address,omitempty"`
+	Name       string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Owner      string `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	Interface  string `protobuf:"bytes,5,opt,name=interface,proto3" json:"interface,omitempty"`
+	Was        bool   `protobuf:"varint,6,opt,name=was,proto3" json:"was,omitempty"`
+	Present    bool   `protobuf:"varint,7,opt,name=present,proto3" json:"present,omitempty"`
+	Source     string `protobuf:"bytes,8,opt,name=source,proto3" json:"source,omitempty"`
+	// Zone is the zone of the household or device which changed. It is empty
+	// for the household of the config.
+	Zone          string `protobuf:"bytes,9,opt,name=zone,proto3" json:"zone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_presence_v1_presence_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Change) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Change) GetMacAddress() string {
+	if x != nil {
+		return x.MacAddress
+	}
+	return ""
+}
+
+func (x *Change) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Change) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Change) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *Change) GetWas() bool {
+	if x != nil {
+		return x.Was
+	}
+	return false
+}
+
+func (x *Change) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *Change) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Change) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+// ConfigChange is a config field which differs after a reload.
+type ConfigChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Field is the dotted path of the field (e.g. "ifttt.base_url").
+	Field string `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// Old and new are the JSON encoded values of the field.
+	Old           string `protobuf:"bytes,2,opt,name=old,proto3" json:"old,omitempty"`
+	New           string `protobuf:"bytes,3,opt,name=new,proto3" json:"new,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigChange) Reset() {
+	*x = ConfigChange{}
+	mi := &file_presence_v1_presence_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigChange) ProtoMessage() {}
+
+func (x *ConfigChange) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigChange.ProtoReflect.Descriptor instead.
+func (*ConfigChange) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConfigChange) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *ConfigChange) GetOld() string {
+	if x != nil {
+		return x.Old
+	}
+	return ""
+}
+
+func (x *ConfigChange) GetNew() string {
+	if x != nil {
+		return x.New
+	}
+	return ""
+}
+
+type GetStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateRequest) Reset() {
+	*x = GetStateRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateRequest) ProtoMessage() {}
+
+func (x *GetStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateRequest.ProtoReflect.Descriptor instead.
+func (*GetStateRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{5}
+}
+
+type GetStateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *State                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStateResponse) Reset() {
+	*x = GetStateResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStateResponse) ProtoMessage() {}
+
+func (x *GetStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStateResponse.ProtoReflect.Descriptor instead.
+func (*GetStateResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetStateResponse) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type WatchStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStateRequest) Reset() {
+	*x = WatchStateRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStateRequest) ProtoMessage() {}
+
+func (x *WatchStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStateRequest.ProtoReflect.Descriptor instead.
+func (*WatchStateRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{7}
+}
+
+type WatchStateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*WatchStateResponse_State
+	//	*WatchStateResponse_Change
+	Event         isWatchStateResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStateResponse) Reset() {
+	*x = WatchStateResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStateResponse) ProtoMessage() {}
+
+func (x *WatchStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStateResponse.ProtoReflect.Descriptor instead.
+func (*WatchStateResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WatchStateResponse) GetEvent() isWatchStateResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *WatchStateResponse) GetState() *State {
+	if x != nil {
+		if x, ok := x.Event.(*WatchStateResponse_State); ok {
+			return x.State
+		}
+	}
+	return nil
+}
+
+func (x *WatchStateResponse) GetChange() *Change {
+	if x != nil {
+		if x, ok := x.Event.(*WatchStateResponse_Change); ok {
+			return x.Change
+		}
+	}
+	return nil
+}
+
+type isWatchStateResponse_Event interface {
+	isWatchStateResponse_Event()
+}
+
+type WatchStateResponse_State struct {
+	// State is the first message of the stream.
+	State *State `protobuf:"bytes,1,opt,name=state,proto3,oneof"`
+}
+
+type WatchStateResponse_Change struct {
+	Change *Change `protobuf:"bytes,2,opt,name=change,proto3,oneof"`
+}
+
+func (*WatchStateResponse_State) isWatchStateResponse_Event() {}
+
+func (*WatchStateResponse_Change) isWatchStateResponse_Event() {}
+
+type TriggerDetectRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerDetectRequest) Reset() {
+	*x = TriggerDetectRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerDetectRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerDetectRequest) ProtoMessage() {}
+
+func (x *TriggerDetectRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerDetectRequest.ProtoReflect.Descriptor instead.
+func (*TriggerDetectRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{9}
+}
+
+type TriggerDetectResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *State                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerDetectResponse) Reset() {
+	*x = TriggerDetectResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerDetectResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerDetectResponse) ProtoMessage() {}
+
+func (x *TriggerDetectResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerDetectResponse.ProtoReflect.Descriptor instead.
+func (*TriggerDetectResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TriggerDetectResponse) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{11}
+}
+
+type ReloadConfigResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// Error is why the reload failed and the old config was kept. It is empty
+	// when the reload succeeded.
+	Error         string          `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Changes       []*ConfigChange `protobuf:"bytes,3,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigResponse) ProtoMessage() {}
+
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReloadConfigResponse) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *ReloadConfigResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ReloadConfigResponse) GetChanges() []*ConfigChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type ListDevicesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Owner only lists the devices of this owner when set.
+	Owner string `protobuf:"bytes,1,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Zone lists the devices of this zone instead of those of the household
+	// of the config when set.
+	Zone          string `protobuf:"bytes,2,opt,name=zone,proto3" json:"zone,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesRequest) Reset() {
+	*x = ListDevicesRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRequest) ProtoMessage() {}
+
+func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
+func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListDevicesRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *ListDevicesRequest) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+type ListDevicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Devices       []*Device              `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesResponse) Reset() {
+	*x = ListDevicesResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesResponse) ProtoMessage() {}
+
+func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
+func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListDevicesResponse) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type SetOverrideRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Target is the MAC address or name of a device, or the owner of devices.
+	Target  string `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	Present bool   `protobuf:"varint,2,opt,name=present,proto3" json:"present,omitempty"`
+	// Duration is how long the override lasts. It does not expire when unset.
+	Duration      *durationpb.Duration `protobuf:"bytes,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	Reason        string               `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetOverrideRequest) Reset() {
+	*x = SetOverrideRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetOverrideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetOverrideRequest) ProtoMessage() {}
+
+func (x *SetOverrideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetOverrideRequest.ProtoReflect.Descriptor instead.
+func (*SetOverrideRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SetOverrideRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SetOverrideRequest) GetPresent() bool {
+	if x != nil {
+		return x.Present
+	}
+	return false
+}
+
+func (x *SetOverrideRequest) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *SetOverrideRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type SetOverrideResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Override      *Override              `protobuf:"bytes,1,opt,name=override,proto3" json:"override,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetOverrideResponse) Reset() {
+	*x = SetOverrideResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetOverrideResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetOverrideResponse) ProtoMessage() {}
+
+func (x *SetOverrideResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetOverrideResponse.ProtoReflect.Descriptor instead.
+func (*SetOverrideResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SetOverrideResponse) GetOverride() *Override {
+	if x != nil {
+		return x.Override
+	}
+	return nil
+}
+
+type ClearOverrideRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Target        string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearOverrideRequest) Reset() {
+	*x = ClearOverrideRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearOverrideRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearOverrideRequest) ProtoMessage() {}
+
+func (x *ClearOverrideRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearOverrideRequest.ProtoReflect.Descriptor instead.
+func (*ClearOverrideRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ClearOverrideRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+type ClearOverrideResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearOverrideResponse) Reset() {
+	*x = ClearOverrideResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearOverrideResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearOverrideResponse) ProtoMessage() {}
+
+func (x *ClearOverrideResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearOverrideResponse.ProtoReflect.Descriptor instead.
+func (*ClearOverrideResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{18}
+}
+
+type ListOverridesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOverridesRequest) Reset() {
+	*x = ListOverridesRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOverridesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOverridesRequest) ProtoMessage() {}
+
+func (x *ListOverridesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOverridesRequest.ProtoReflect.Descriptor instead.
+func (*ListOverridesRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{19}
+}
+
+type ListOverridesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Overrides     []*Override            `protobuf:"bytes,1,rep,name=overrides,proto3" json:"overrides,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOverridesResponse) Reset() {
+	*x = ListOverridesResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOverridesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOverridesResponse) ProtoMessage() {}
+
+func (x *ListOverridesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOverridesResponse.ProtoReflect.Descriptor instead.
+func (*ListOverridesResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListOverridesResponse) GetOverrides() []*Override {
+	if x != nil {
+		return x.Overrides
+	}
+	return nil
+}
+
+type SetGuestModeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetGuestModeRequest) Reset() {
+	*x = SetGuestModeRequest{}
+	mi := &file_presence_v1_presence_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetGuestModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetGuestModeRequest) ProtoMessage() {}
+
+func (x *SetGuestModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetGuestModeRequest.ProtoReflect.Descriptor instead.
+func (*SetGuestModeRequest) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SetGuestModeRequest) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+type SetGuestModeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *State                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetGuestModeResponse) Reset() {
+	*x = SetGuestModeResponse{}
+	mi := &file_presence_v1_presence_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetGuestModeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetGuestModeResponse) ProtoMessage() {}
+
+func (x *SetGuestModeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_presence_v1_presence_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetGuestModeResponse.ProtoReflect.Descriptor instead.
+func (*SetGuestModeResponse) Descriptor() ([]byte, []int) {
+	return file_presence_v1_presence_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SetGuestModeResponse) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+var File_presence_v1_presence_proto protoreflect.FileDescriptor
+
+const file_presence_v1_presence_proto_rawDesc = "" +
+	"\n" +
+	"\x1apresence/v1/presence.proto\x12\vpresence.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfe\x02\n" +
+	"\x05State\x12\x18\n" +
+	"\apresent\x18\x01 \x01(\bR\apresent\x120\n" +
+	"\x05since\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1a\n" +
+	"\bflapping\x18\x03 \x01(\bR\bflapping\x12B\n" +
+	"\n" +
+	"suppressed\x18\x04 \x03(\v2\".presence.v1.State.SuppressedEntryR\n" +
+	"suppressed\x12-\n" +
+	"\adevices\x18\x05 \x03(\v2\x13.presence.v1.DeviceR\adevices\x12\x1d\n" +
+	"\n" +
+	"guest_mode\x18\x06 \x01(\bR\tguestMode\x12\x12\n" +
+	"\x04zone\x18\a \x01(\tR\x04zone\x12(\n" +
+	"\x05zones\x18\b \x03(\v2\x12.presence.v1.StateR\x05zones\x1a=\n" +
+	"\x0fSuppressedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\rR\x05value:\x028\x01\"\x95\x03\n" +
+	"\x06Device\x12\x1f\n" +
+	"\vmac_address\x18\x01 \x01(\tR\n" +
+	"macAddress\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x12\n" +
+	"\x04icon\x18\x05 \x01(\tR\x04icon\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x18\n" +
+	"\apresent\x18\a \x01(\bR\apresent\x12\x1c\n" +
+	"\tinterface\x18\b \x01(\tR\tinterface\x120\n" +
+	"\x05since\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x1a\n" +
+	"\bflapping\x18\n" +
+	" \x01(\bR\bflapping\x127\n" +
+	"\tlast_seen\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x121\n" +
+	"\boverride\x18\f \x01(\v2\x15.presence.v1.OverrideR\boverride\x12\x12\n" +
+	"\x04role\x18\r \x01(\tR\x04role\"\xbc\x01\n" +
+	"\bOverride\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x18\n" +
+	"\apresent\x18\x02 \x01(\bR\apresent\x120\n" +
+	"\x05until\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05until\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x124\n" +
+	"\acreated\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\"\xf9\x01\n" +
+	"\x06Change\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1f\n" +
+	"\vmac_address\x18\x02 \x01(\tR\n" +
+	"macAddress\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12\x1c\n" +
+	"\tinterface\x18\x05 \x01(\tR\tinterface\x12\x10\n" +
+	"\x03was\x18\x06 \x01(\bR\x03was\x12\x18\n" +
+	"\apresent\x18\a \x01(\bR\apresent\x12\x16\n" +
+	"\x06source\x18\b \x01(\tR\x06source\x12\x12\n" +
+	"\x04zone\x18\t \x01(\tR\x04zone\"H\n" +
+	"\fConfigChange\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x10\n" +
+	"\x03old\x18\x02 \x01(\tR\x03old\x12\x10\n" +
+	"\x03new\x18\x03 \x01(\tR\x03new\"\x11\n" +
+	"\x0fGetStateRequest\"<\n" +
+	"\x10GetStateResponse\x12(\n" +
+	"\x05state\x18\x01 \x01(\v2\x12.presence.v1.StateR\x05state\"\x13\n" +
+	"\x11WatchStateRequest\"x\n" +
+	"\x12WatchStateResponse\x12*\n" +
+	"\x05state\x18\x01 \x01(\v2\x12.presence.v1.StateH\x00R\x05state\x12-\n" +
+	"\x06change\x18\x02 \x01(\v2\x13.presence.v1.ChangeH\x00R\x06changeB\a\n" +
+	"\x05event\"\x16\n" +
+	"\x14TriggerDetectRequest\"A\n" +
+	"\x15TriggerDetectResponse\x12(\n" +
+	"\x05state\x18\x01 \x01(\v2\x12.presence.v1.StateR\x05state\"\x15\n" +
+	"\x13ReloadConfigRequest\"\x91\x01\n" +
+	"\x14ReloadConfigResponse\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x123\n" +
+	"\achanges\x18\x03 \x03(\v2\x19.presence.v1.ConfigChangeR\achanges\">\n" +
+	"\x12ListDevicesRequest\x12\x14\n" +
+	"\x05owner\x18\x01 \x01(\tR\x05owner\x12\x12\n" +
+	"\x04zone\x18\x02 \x01(\tR\x04zone\"D\n" +
+	"\x13ListDevicesResponse\x12-\n" +
+	"\adevices\x18\x01 \x03(\v2\x13.presence.v1.DeviceR\adevices\"\x95\x01\n" +
+	"\x12SetOverrideRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x18\n" +
+	"\apresent\x18\x02 \x01(\bR\apresent\x125\n" +
+	"\bduration\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\bduration\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"H\n" +
+	"\x13SetOverrideResponse\x121\n" +
+	"\boverride\x18\x01 \x01(\v2\x15.presence.v1.OverrideR\boverride\".\n" +
+	"\x14ClearOverrideRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\"\x17\n" +
+	"\x15ClearOverrideResponse\"\x16\n" +
+	"\x14ListOverridesRequest\"L\n" +
+	"\x15ListOverridesResponse\x123\n" +
+	"\toverrides\x18\x01 \x03(\v2\x15.presence.v1.OverrideR\toverrides\"/\n" +
+	"\x13SetGuestModeRequest\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\"@\n" +
+	"\x14SetGuestModeResponse\x12(\n" +
+	"\x05state\x18\x01 \x01(\v2\x12.presence.v1.StateR\x05state2\x81\x06\n" +
+	"\x0fPresenceService\x12G\n" +
+	"\bGetState\x12\x1c.presence.v1.GetStateRequest\x1a\x1d.presence.v1.GetStateResponse\x12O\n" +
+	"\n" +
+	"WatchState\x12\x1e.presence.v1.WatchStateRequest\x1a\x1f.presence.v1.WatchStateResponse0\x01\x12V\n" +
+	"\rTriggerDetect\x12!.presence.v1.TriggerDetectRequest\x1a\".presence.v1.TriggerDetectResponse\x12S\n" +
+	"\fReloadConfig\x12 .presence.v1.ReloadConfigRequest\x1a!.presence.v1.ReloadConfigResponse\x12P\n" +
+	"\vListDevices\x12\x1f.presence.v1.ListDevicesRequest\x1a .presence.v1.ListDevicesResponse\x12P\n" +
+	"\vSetOverride\x12\x1f.presence.v1.SetOverrideRequest\x1a .presence.v1.SetOverrideResponse\x12V\n" +
+	"\rClearOverride\x12!.presence.v1.ClearOverrideRequest\x1a\".presence.v1.ClearOverrideResponse\x12V\n" +
+	"\rListOverrides\x12!.presence.v1.ListOverridesRequest\x1a\".presence.v1.ListOverridesResponse\x12S\n" +
+	"\fSetGuestMode\x12 .presence.v1.SetGuestModeRequest\x1a!.presence.v1.SetGuestModeResponseB7Z5douglasthrift.net/presence/api/presence/v1;presencev1b\x06proto3"
+
+var (
+	file_presence_v1_presence_proto_rawDescOnce sync.Once
+	file_presence_v1_presence_proto_rawDescData []byte
+)
+
+func file_presence_v1_presence_proto_rawDescGZIP() []byte {
+	file_presence_v1_presence_proto_rawDescOnce.Do(func() {
+		file_presence_v1_presence_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_presence_v1_presence_proto_rawDesc), len(file_presence_v1_presence_proto_rawDesc)))
+	})
+	return file_presence_v1_presence_proto_rawDescData
+}
+
+var file_presence_v1_presence_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_presence_v1_presence_proto_goTypes = []any{
+	(*State)(nil),                 // 0: presence.v1.State
+	(*Device)(nil),                // 1: presence.v1.Device
+	(*Override)(nil),              // 2: presence.v1.Override
+	(*Change)(nil),                // 3: presence.v1.Change
+	(*ConfigChange)(nil),          // 4: presence.v1.ConfigChange
+	(*GetStateRequest)(nil),       // 5: presence.v1.GetStateRequest
+	(*GetStateResponse)(nil),      // 6: presence.v1.GetStateResponse
+	(*WatchStateRequest)(nil),     // 7: presence.v1.WatchStateRequest
+	(*WatchStateResponse)(nil),    // 8: presence.v1.WatchStateResponse
+	(*TriggerDetectRequest)(nil),  // 9: presence.v1.TriggerDetectRequest
+	(*TriggerDetectResponse)(nil), // 10: presence.v1.TriggerDetectResponse
+	(*ReloadConfigRequest)(nil),   // 11: presence.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),  // 12: presence.v1.ReloadConfigResponse
+	(*ListDevicesRequest)(nil),    // 13: presence.v1.ListDevicesRequest
+	(*ListDevicesResponse)(nil),   // 14: presence.v1.ListDevicesResponse
+	(*SetOverrideRequest)(nil),    // 15: presence.v1.SetOverrideRequest
+	(*SetOverrideResponse)(nil),   // 16: presence.v1.SetOverrideResponse
+	(*ClearOverrideRequest)(nil),  // 17: presence.v1.ClearOverrideRequest
+	(*ClearOverrideResponse)(nil), // 18: presence.v1.ClearOverrideResponse
+	(*ListOverridesRequest)(nil),  // 19: presence.v1.ListOverridesRequest
+	(*ListOverridesResponse)(nil), // 20: presence.v1.ListOverridesResponse
+	(*SetGuestModeRequest)(nil),   // 21: presence.v1.SetGuestModeRequest
+	(*SetGuestModeResponse)(nil),  // 22: presence.v1.SetGuestModeResponse
+	nil,                           // 23: presence.v1.State.SuppressedEntry
+	(*timestamppb.Timestamp)(nil), // 24: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 25: google.protobuf.Duration
+}
+var file_presence_v1_presence_proto_depIdxs = []int32{
+	24, // 0: presence.v1.State.since:type_name -> google.protobuf.Timestamp
+	23, // 1: presence.v1.State.suppressed:type_name -> presence.v1.State.SuppressedEntry
+	1,  // 2: presence.v1.State.devices:type_name -> presence.v1.Device
+	0,  // 3: presence.v1.State.zones:type_name -> presence.v1.State
+	24, // 4: presence.v1.Device.since:type_name -> google.protobuf.Timestamp
+	24, // 5: presence.v1.Device.last_seen:type_name -> google.protobuf.Timestamp
+	2,  // 6: presence.v1.Device.override:type_name -> presence.v1.Override
+	24, // 7: presence.v1.Override.until:type_name -> google.protobuf.Timestamp
+	24, // 8: presence.v1.Override.created:type_name -> google.protobuf.Timestamp
+	24, // 9: presence.v1.Change.time:type_name -> google.protobuf.Timestamp
+	0,  // 10: presence.v1.GetStateResponse.state:type_name -> presence.v1.State
+	0,  // 11: presence.v1.WatchStateResponse.state:type_name -> presence.v1.State
+	3,  // 12: presence.v1.WatchStateResponse.change:type_name -> presence.v1.Change
+	0,  // 13: presence.v1.TriggerDetectResponse.state:type_name -> presence.v1.State
+	24, // 14: presence.v1.ReloadConfigResponse.time:type_name -> google.protobuf.Timestamp
+	4,  // 15: presence.v1.ReloadConfigResponse.changes:type_name -> presence.v1.ConfigChange
+	1,  // 16: presence.v1.ListDevicesResponse.devices:type_name -> presence.v1.Device
+	25, // 17: presence.v1.SetOverrideRequest.duration:type_name -> google.protobuf.Duration
+	2,  // 18: presence.v1.SetOverrideResponse.override:type_name -> presence.v1.Override
+	2,  // 19: presence.v1.ListOverridesResponse.overrides:type_name -> presence.v1.Override
+	0,  // 20: presence.v1.SetGuestModeResponse.state:type_name -> presence.v1.State
+	5,  // 21: presence.v1.PresenceService.GetState:input_type -> presence.v1.GetStateRequest
+	7,  // 22: presence.v1.PresenceService.WatchState:input_type -> presence.v1.WatchStateRequest
+	9,  // 23: presence.v1.PresenceService.TriggerDetect:input_type -> presence.v1.TriggerDetectRequest
+	11, // 24: presence.v1.PresenceService.ReloadConfig:input_type -> presence.v1.ReloadConfigRequest
+	13, // 25: presence.v1.PresenceService.ListDevices:input_type -> presence.v1.ListDevicesRequest
+	15, // 26: presence.v1.PresenceService.SetOverride:input_type -> presence.v1.SetOverrideRequest
+	17, // 27: presence.v1.PresenceService.ClearOverride:input_type -> presence.v1.ClearOverrideRequest
+	19, // 28: presence.v1.PresenceService.ListOverrides:input_type -> presence.v1.ListOverridesRequest
+	21, // 29: presence.v1.PresenceService.SetGuestMode:input_type -> presence.v1.SetGuestModeRequest
+	6,  // 30: presence.v1.PresenceService.GetState:output_type -> presence.v1.GetStateResponse
+	8,  // 31: presence.v1.PresenceService.WatchState:output_type -> presence.v1.WatchStateResponse
+	10, // 32: presence.v1.PresenceService.TriggerDetect:output_type -> presence.v1.TriggerDetectResponse
+	12, // 33: presence.v1.PresenceService.ReloadConfig:output_type -> presence.v1.ReloadConfigResponse
+	14, // 34: presence.v1.PresenceService.ListDevices:output_type -> presence.v1.ListDevicesResponse
+	16, // 35: presence.v1.PresenceService.SetOverride:output_type -> presence.v1.SetOverrideResponse
+	18, // 36: presence.v1.PresenceService.ClearOverride:output_type -> presence.v1.ClearOverrideResponse
+	20, // 37: presence.v1.PresenceService.ListOverrides:output_type -> presence.v1.ListOverridesResponse
+	22, // 38: presence.v1.PresenceService.SetGuestMode:output_type -> presence.v1.SetGuestModeResponse
+	30, // [30:39] is the sub-list for method output_type
+	21, // [21:30] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_presence_v1_presence_proto_init() }
+func file_presence_v1_presence_proto_init() {
+	if File_presence_v1_presence_proto != nil {
+		return
+	}
+	file_presence_v1_presence_proto_msgTypes[8].OneofWrappers = []any{
+		(*WatchStateResponse_State)(nil),
+		(*WatchStateResponse_Change)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_presence_v1_presence_proto_rawDesc), len(file_presence_v1_presence_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_presence_v1_presence_proto_goTypes,
+		DependencyIndexes: file_presence_v1_presence_proto_depIdxs,
+		MessageInfos:      file_presence_v1_presence_proto_msgTypes,
+	}.Build()
+	File_presence_v1_presence_proto = out.File
+	file_presence_v1_presence_proto_goTypes = nil
+	file_presence_v1_presence_proto_depIdxs = nil
+}
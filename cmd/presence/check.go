@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"goa.design/clue/log"
 
 	"douglasthrift.net/presence"
@@ -15,10 +18,24 @@ type (
 
 func (c *Check) Run(cli *CLI) (err error) {
 	ctx := cli.Context()
+	if cli.Config != "" {
+		errs, err := presence.ValidateConfig(cli.Config)
+		if err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "error reading config"}, log.KV{K: "config", V: cli.Config})
+		}
+		for _, e := range errs {
+			log.Error(ctx, e, log.KV{K: "msg", V: "invalid config"}, log.KV{K: "config", V: e.File},
+				log.KV{K: "line", V: e.Line}, log.KV{K: "column", V: e.Column}, log.KV{K: "field", V: e.Field})
+		}
+		if len(errs) != 0 {
+			log.Fatal(ctx, fmt.Errorf("%d schema errors", len(errs)), log.KV{K: "msg", V: "invalid config"}, log.KV{K: "config", V: cli.Config})
+		}
+	}
+
 	if c.Values {
-		_, err = presence.ParseConfigWithContext(ctx, cli.Config, wNet)
+		_, err = presence.ParseConfigWithOverrides(ctx, cli.Config, cli.Set, wNet)
 	} else {
-		_, err = presence.ParseConfig(cli.Config, wNet)
+		_, err = presence.ParseConfigWithOverrides(context.Background(), cli.Config, cli.Set, wNet)
 	}
 	if err != nil {
 		log.Fatal(ctx, err, log.KV{K: "msg", V: "error parsing config"}, log.KV{K: "config", V: cli.Config})
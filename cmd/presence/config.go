@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"douglasthrift.net/presence"
+)
+
+type (
+	ConfigCmd struct {
+		Schema ConfigSchema `cmd:"" help:"Print the JSON Schema of the configuration file."`
+	}
+
+	ConfigSchema struct{}
+)
+
+func (c *ConfigSchema) Run(cli *CLI) error {
+	e := json.NewEncoder(os.Stdout)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(presence.ConfigSchema())
+}
@@ -1,123 +1,152 @@
 package main
 
 import (
-	"net/http"
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"goa.design/clue/log"
 
 	"douglasthrift.net/presence"
-	"douglasthrift.net/presence/ifttt"
-	"douglasthrift.net/presence/neighbors"
 )
 
 type (
 	Detect struct {
-		Iterations uint `help:"Only detect for N iterations." placeholder:"N" short:"i"`
+		Iterations uint          `help:"Only detect for N iterations." placeholder:"N" short:"i"`
+		Watch      bool          `help:"Reload configuration when it, its fragments or the IFTTT key file change." short:"w"`
+		WatchDelay time.Duration `default:"1s" help:"Wait for changes to settle for this long before reloading."`
+		Listen     string        `help:"Serve status and a dashboard over HTTP on ADDRESS." placeholder:"ADDRESS" short:"l"`
+		Keepalive  time.Duration `default:"15s" help:"Send a keepalive comment on the HTTP events stream this often."`
+		RPCListen  string        `help:"Serve the gRPC control API on ADDRESS." name:"rpc-listen" placeholder:"ADDRESS"`
+		RPCCert    string        `help:"Serve the gRPC control API over TLS with the certificate in FILE." name:"rpc-cert" placeholder:"FILE" type:"existingfile"`
+		RPCKey     string        `help:"Serve the gRPC control API over TLS with the key in FILE." name:"rpc-key" placeholder:"FILE" type:"existingfile"`
+		RPCCA      string        `help:"Require gRPC clients to present a certificate signed by one in FILE." name:"rpc-client-ca" placeholder:"FILE" type:"existingfile"`
+	}
+
+	// reloader reloads the runtime of a runner one reload at a time.
+	reloader struct {
+		cli    *CLI
+		runner *presence.Runner
+		status *statusServer
+		mu     sync.Mutex
 	}
 )
 
 func (d *Detect) Run(cli *CLI) error {
 	ctx := cli.Context()
-	config, err := presence.ParseConfigWithContext(ctx, cli.Config, wNet)
-	if err != nil {
-		log.Fatal(ctx, err, log.KV{K: "msg", V: "error parsing config"}, log.KV{K: "config", V: cli.Config})
-	}
-
-	arp, err := neighbors.NewARP(config.PingCount)
+	rt, err := presence.NewRuntime(ctx, cli.Config, cli.Set, wNet, wClock, cli.Debug)
 	if err != nil {
-		log.Fatal(ctx, err, log.KV{K: "msg", V: "error finding dependencies"})
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error loading config"}, log.KV{K: "config", V: cli.Config})
 	}
 
-	client, err := ifttt.NewClient(http.DefaultClient, config.IFTTT.BaseURL, config.IFTTT.Key,
-		config.IFTTT.Events.Present.Event, config.IFTTT.Events.Absent.Event, ifttt.Values{
-			Value1: config.IFTTT.Events.Present.Value1,
-			Value2: config.IFTTT.Events.Present.Value2,
-			Value3: config.IFTTT.Events.Present.Value3,
-		}, ifttt.Values{
-			Value1: config.IFTTT.Events.Absent.Value1,
-			Value2: config.IFTTT.Events.Absent.Value2,
-			Value3: config.IFTTT.Events.Absent.Value3,
-		}, cli.Debug)
-	if err != nil {
-		log.Fatal(ctx, err, log.KV{K: "msg", V: "error creating IFTTT client"})
-	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	var (
-		detector = presence.NewDetector(config, arp, client)
-		ticker   = time.NewTicker(config.Interval)
-		stop     = make(chan os.Signal, 1)
-		reload   = make(chan os.Signal, 1)
-		i        uint
+		runner = presence.NewRunner(rt, wClock)
+		stop   = make(chan os.Signal, 1)
+		reload = make(chan os.Signal, 1)
+		w      *watcher
+		watch  <-chan struct{}
+		status *statusServer
+		rpcs   *rpcServer
+		i      uint
 	)
 
-	err = detector.Detect(ctx)
-	if err != nil {
-		log.Error(ctx, err, log.KV{K: "msg", V: "error detecting presence"})
+	if d.Iterations != 0 {
+		runner.OnDetect(func(ctx context.Context, err error) {
+			i++
+			if i >= d.Iterations {
+				cancel()
+			}
+		})
 	}
 
-	if d.Iterations != 0 {
-		i++
-		if i >= d.Iterations {
-			ticker.Stop()
-			return nil
+	if d.Watch {
+		w, err = newWatcher(ctx, d.WatchDelay, presence.ConfigPatterns(cli.Config, rt.Config)...)
+		if err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "error watching config"}, log.KV{K: "config", V: cli.Config})
+		}
+		defer func() { _ = w.Close() }()
+		watch = w.C
+	}
+
+	if d.Listen != "" {
+		status, err = newStatusServer(ctx, d.Listen, runner, d.Keepalive)
+		if err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "error serving status"}, log.KV{K: "address", V: d.Listen})
 		}
+		defer func() { _ = status.Close() }()
+	}
+	r := &reloader{cli: cli, runner: runner, status: status}
+
+	if d.RPCListen != "" {
+		rpcs, err = d.newRPCServer(ctx, runner, r.reload)
+		if err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "error serving gRPC"}, log.KV{K: "address", V: d.RPCListen})
+		}
+		defer func() { _ = rpcs.Close() }()
 	}
 
 	signal.Ignore(syscall.SIGHUP)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	signal.Notify(reload, syscall.SIGUSR1)
+	defer signal.Stop(stop)
+	defer signal.Stop(reload)
 
-	for {
-		select {
-		case <-ticker.C:
-			err = detector.Detect(ctx)
-			if err != nil {
-				log.Error(ctx, err, log.KV{K: "msg", V: "error detecting presence"})
-			}
-
-			if d.Iterations != 0 {
-				i++
-				if i >= d.Iterations {
-					ticker.Stop()
-					return nil
-				}
-			}
-		case s := <-stop:
-			log.Print(ctx, log.Fields{"msg": "received stop signal"}, log.Fields{"signal": s})
-			ticker.Stop()
-			return nil
-		case s := <-reload:
-			log.Print(ctx, log.Fields{"msg": "received reload signal"}, log.Fields{"signal": s})
-			config, err = presence.ParseConfigWithContext(ctx, cli.Config, wNet)
-			if err != nil {
-				log.Error(ctx, err, log.KV{K: "msg", V: "error parsing config"}, log.KV{K: "config", V: cli.Config})
-			} else if client, err = ifttt.NewClient(http.DefaultClient, config.IFTTT.BaseURL, config.IFTTT.Key,
-				config.IFTTT.Events.Present.Event, config.IFTTT.Events.Absent.Event, ifttt.Values{
-					Value1: config.IFTTT.Events.Present.Value1,
-					Value2: config.IFTTT.Events.Present.Value2,
-					Value3: config.IFTTT.Events.Present.Value3,
-				}, ifttt.Values{
-					Value1: config.IFTTT.Events.Absent.Value1,
-					Value2: config.IFTTT.Events.Absent.Value2,
-					Value3: config.IFTTT.Events.Absent.Value3,
-				}, cli.Debug); err != nil {
-				log.Error(ctx, err, log.KV{K: "msg", V: "error creating IFTTT client"})
-			} else {
-				arp.Count(config.PingCount)
-				detector.Config(config)
-				detector.Client(client)
-
-				err = detector.Detect(ctx)
-				if err != nil {
-					log.Error(ctx, err, log.KV{K: "msg", V: "error detecting presence"})
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case s := <-stop:
+				log.Print(ctx, log.Fields{"msg": "received stop signal"}, log.Fields{"signal": s})
+				cancel()
+				return
+			case s := <-reload:
+				log.Print(ctx, log.Fields{"msg": "received reload signal"}, log.Fields{"signal": s})
+				r.reload(ctx, "signal")
+			case <-watch:
+				log.Print(ctx, log.Fields{"msg": "config changed"}, log.Fields{"config": cli.Config})
+				r.reload(ctx, "watch")
+				if err := w.Watch(ctx, presence.ConfigPatterns(cli.Config, runner.Runtime().Config)...); err != nil {
+					log.Error(ctx, err, log.KV{K: "msg", V: "error watching config"}, log.KV{K: "config", V: cli.Config})
 				}
-
-				ticker.Reset(config.Interval)
 			}
 		}
+	}()
+
+	return runner.Run(ctx)
+}
+
+// reload builds a new runtime from the config and swaps it into the
+// runner only if it was built successfully.
+func (r *reloader) reload(ctx context.Context, reason string) *presence.ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cli := r.cli
+	rt, result := presence.Reload(ctx, r.runner.Runtime(), cli.Config, cli.Set, wNet, wClock, cli.Debug, reason)
+	if r.status != nil {
+		r.status.Reloaded(result)
+	}
+
+	if result.Error != "" {
+		log.Print(ctx, log.KV{K: "msg", V: "reload failed, keeping old config"}, log.KV{K: "config", V: cli.Config},
+			log.KV{K: "reason", V: result.Reason}, log.KV{K: "error", V: result.Error})
+		return result
 	}
+
+	for _, c := range result.Changes {
+		log.Print(ctx, log.KV{K: "msg", V: "config field changed"}, log.KV{K: "field", V: c.Field},
+			log.KV{K: "old", V: c.Old}, log.KV{K: "new", V: c.New})
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "reloaded config"}, log.KV{K: "config", V: cli.Config},
+		log.KV{K: "reason", V: result.Reason}, log.KV{K: "changes", V: len(result.Changes)})
+
+	r.runner.Replace(rt)
+	return result
 }
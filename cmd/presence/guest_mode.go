@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"goa.design/clue/log"
+
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+)
+
+type (
+	GuestMode struct {
+		rpcClient
+
+		State string `arg:"" enum:"on,off" help:"Count guest devices toward the household (on) or not (off)."`
+	}
+)
+
+// Run toggles guest mode in a running daemon. Logs go to standard error so
+// that the output can be redirected.
+func (g *GuestMode) Run(cli *CLI) error {
+	ctx := log.Context(context.Background(), log.WithOutput(os.Stderr))
+	client, closeClient, err := g.client()
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error connecting"}, log.KV{K: "server", V: g.Server})
+	}
+	defer func() { _ = closeClient() }()
+
+	resp, err := client.SetGuestMode(ctx, &presencev1.SetGuestModeRequest{Enabled: g.State == "on"})
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error setting guest mode"})
+	}
+	household := "away"
+	if resp.State.Present {
+		household = "home"
+	}
+	_, err = fmt.Printf("guest mode %v, household %v\n", g.State, household)
+	return err
+}
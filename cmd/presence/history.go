@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence"
+	"douglasthrift.net/presence/history"
+)
+
+type (
+	History struct {
+		Device    string `help:"Only show transitions of the device with this MAC address or name." short:"D"`
+		Owner     string `help:"Only show transitions of the devices owned by this person."`
+		Household bool   `help:"Only show transitions of the household."`
+		Zone      string `help:"Only show transitions of the household and devices of this zone."`
+		Since     string `help:"Only show transitions at or after TIME (e.g. yesterday, 2024-01-02, 2024-01-02T15:04:05Z or 2h)." placeholder:"TIME"`
+		Until     string `help:"Only show transitions before TIME." placeholder:"TIME"`
+		Format    string `default:"table" enum:"table,csv,json" help:"Output format (${enum})."`
+	}
+)
+
+var (
+	historyHeader = []string{"TIME", "DEVICE", "MAC ADDRESS", "OWNER", "STATE", "INTERFACE", "ZONE"}
+)
+
+// Run prints the recorded transitions. Logs go to standard error so that
+// the output can be redirected.
+func (h *History) Run(cli *CLI) error {
+	ctx := log.Context(context.Background(), log.WithOutput(os.Stderr))
+	filter, err := h.filter(wClock.Now())
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "invalid filter"})
+	}
+
+	return writeHistory(os.Stdout, h.Format, queryHistory(ctx, cli, filter))
+}
+
+// queryHistory returns the events in the configured history selected by the
+// filter.
+func queryHistory(ctx context.Context, cli *CLI, filter history.Filter) []history.Event {
+	config, err := presence.ParseConfigWithOverrides(context.Background(), cli.Config, cli.Set, wNet)
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error parsing config"}, log.KV{K: "config", V: cli.Config})
+	}
+	if config.History.File == "" {
+		log.Fatal(ctx, errors.New("no history file configured"), log.KV{K: "msg", V: "error reading history"}, log.KV{K: "config", V: cli.Config})
+	}
+
+	events, err := history.New(config.History.File, config.History.Retention(), wClock).Query(filter)
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error reading history"}, log.KV{K: "file", V: config.History.File})
+	}
+	return events
+}
+
+func (h *History) filter(now time.Time) (f history.Filter, err error) {
+	f = history.Filter{
+		Device:    h.Device,
+		Owner:     h.Owner,
+		Household: h.Household,
+		Zone:      h.Zone,
+	}
+	if hw, err := net.ParseMAC(h.Device); err == nil {
+		f.Device = hw.String()
+	}
+	if h.Since != "" {
+		if f.Since, err = history.ParseTime(h.Since, now); err != nil {
+			return f, fmt.Errorf("since: %w", err)
+		}
+	}
+	if h.Until != "" {
+		if f.Until, err = history.ParseTime(h.Until, now); err != nil {
+			return f, fmt.Errorf("until: %w", err)
+		}
+	}
+	return f, nil
+}
+
+func writeHistory(w io.Writer, format string, events []history.Event) error {
+	switch format {
+	case "json":
+		e := json.NewEncoder(w)
+		e.SetEscapeHTML(false)
+		e.SetIndent("", "  ")
+		return e.Encode(events)
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write(historyHeader)
+		for _, e := range events {
+			_ = cw.Write(historyRow(e, time.RFC3339))
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		writeTabbed(tw, historyHeader)
+		for _, e := range events {
+			writeTabbed(tw, historyRow(e, time.DateTime))
+		}
+		return tw.Flush()
+	}
+}
+
+func historyRow(e history.Event, layout string) []string {
+	state := "absent"
+	if e.Present {
+		state = "present"
+	}
+	return []string{e.Time.Local().Format(layout), e.Label(), e.MACAddress, e.Owner, state, e.Interface, e.Zone}
+}
+
+func writeTabbed(w io.Writer, fields []string) {
+	for i, f := range fields {
+		if i != 0 {
+			_, _ = io.WriteString(w, "\t")
+		}
+		if f == "" {
+			f = "-"
+		}
+		_, _ = io.WriteString(w, f)
+	}
+	_, _ = io.WriteString(w, "\n")
+}
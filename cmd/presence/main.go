@@ -10,17 +10,25 @@ import (
 	"github.com/alecthomas/kong"
 	"goa.design/clue/log"
 
+	"douglasthrift.net/presence"
 	"douglasthrift.net/presence/wrap"
 )
 
 type (
 	CLI struct {
-		Config  string           `default:"${config}" help:"Set the configuration file." short:"c" type:"existingfile"`
-		Debug   bool             `help:"Show debug information in log." short:"d"`
-		Version kong.VersionFlag `help:"Show version information." short:"v"`
+		Config  string                   `default:"${config}" env:"PRESENCE_CONFIG" help:"Set the configuration file (empty for none)." short:"c" type:"path"`
+		Set     presence.ConfigOverrides `help:"Override a configuration FIELD (e.g. ifttt.key) with VALUE, taking precedence over PRESENCE_* environment variables and the configuration file." placeholder:"FIELD=VALUE" short:"s"`
+		Debug   bool                     `help:"Show debug information in log." short:"d"`
+		Version kong.VersionFlag         `help:"Show version information." short:"v"`
 
-		Detect Detect `cmd:"" help:"Detect network presence and push state changes to IFTTT."`
-		Check  Check  `cmd:"" help:"Check configuration."`
+		Detect    Detect    `cmd:"" help:"Detect network presence and push state changes to IFTTT."`
+		Check     Check     `cmd:"" help:"Check configuration."`
+		ConfigCmd ConfigCmd `cmd:"" help:"Configuration file commands." name:"config"`
+		History   History   `cmd:"" help:"Show recorded presence transitions."`
+		Report    Report    `cmd:"" help:"Summarize recorded presence transitions."`
+		Override  Override  `cmd:"" help:"Override the state of a device or person in a running daemon."`
+		Overrides Overrides `cmd:"" help:"Show the overrides of a running daemon."`
+		GuestMode GuestMode `cmd:"" help:"Toggle whether guest devices count toward the household in a running daemon."`
 	}
 )
 
@@ -29,6 +37,7 @@ var (
 	commit  = "none"
 	date    = "unknown"
 	wNet    = wrap.NewNet()
+	wClock  = wrap.NewClock()
 )
 
 func init() {
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"goa.design/clue/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+)
+
+type (
+	Override struct {
+		rpcClient
+
+		Target string `arg:"" help:"MAC address or name of a device, or the owner of devices."`
+		State  string `arg:"" enum:"home,away,present,absent,clear" help:"Pin the target home (present) or away (absent), or clear its override (${enum})."`
+		For    string `help:"Expire the override after DURATION (e.g. 7d or 12h) instead of never." placeholder:"DURATION"`
+		Reason string `help:"Record why the state was overridden (e.g. vacation)."`
+	}
+
+	Overrides struct {
+		rpcClient
+
+		Format string `default:"table" enum:"table,json" help:"Output format (${enum})."`
+	}
+)
+
+var (
+	overridesHeader = []string{"TARGET", "STATE", "UNTIL", "REASON", "CREATED"}
+	days            = regexp.MustCompile(`^(\d+)d`)
+)
+
+// Run overrides the state of a device or person in a running daemon. Logs go
+// to standard error so that the output can be redirected.
+func (o *Override) Run(cli *CLI) error {
+	ctx := log.Context(context.Background(), log.WithOutput(os.Stderr))
+	client, closeClient, err := o.client()
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error connecting"}, log.KV{K: "server", V: o.Server})
+	}
+	defer func() { _ = closeClient() }()
+
+	if o.State == "clear" {
+		if _, err = client.ClearOverride(ctx, &presencev1.ClearOverrideRequest{Target: o.Target}); err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "error clearing override"}, log.KV{K: "target", V: o.Target})
+		}
+		_, err = fmt.Printf("cleared override of %v\n", o.Target)
+		return err
+	}
+
+	req := &presencev1.SetOverrideRequest{
+		Target:  o.Target,
+		Present: o.State == "home" || o.State == "present",
+		Reason:  o.Reason,
+	}
+	if o.For != "" {
+		d, err := parseDuration(o.For)
+		if err != nil {
+			log.Fatal(ctx, err, log.KV{K: "msg", V: "invalid duration"})
+		}
+		req.Duration = durationpb.New(d)
+	}
+
+	resp, err := client.SetOverride(ctx, req)
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error setting override"}, log.KV{K: "target", V: o.Target})
+	}
+	return writeOverrides(os.Stdout, "table", []*presencev1.Override{resp.Override})
+}
+
+// Run prints the overrides of a running daemon. Logs go to standard error so
+// that the output can be redirected.
+func (o *Overrides) Run(cli *CLI) error {
+	ctx := log.Context(context.Background(), log.WithOutput(os.Stderr))
+	client, closeClient, err := o.client()
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error connecting"}, log.KV{K: "server", V: o.Server})
+	}
+	defer func() { _ = closeClient() }()
+
+	resp, err := client.ListOverrides(ctx, &presencev1.ListOverridesRequest{})
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "error listing overrides"})
+	}
+	return writeOverrides(os.Stdout, o.Format, resp.Overrides)
+}
+
+// parseDuration parses a duration which may start with a number of days
+// (e.g. 7d or 1d12h).
+func parseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	if m := days.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %#v", s)
+		}
+		d, s = time.Duration(n)*24*time.Hour, s[len(m[0]):]
+		if s == "" {
+			return d, nil
+		}
+	}
+
+	rest, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return d + rest, nil
+}
+
+func writeOverrides(w io.Writer, format string, overrides []*presencev1.Override) error {
+	if format == "json" {
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", UseProtoNames: true}.Marshal(&presencev1.ListOverridesResponse{Overrides: overrides})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeTabbed(tw, overridesHeader)
+	for _, o := range overrides {
+		state, until := "away", "never"
+		if o.Present {
+			state = "home"
+		}
+		if o.Until != nil {
+			until = o.Until.AsTime().Local().Format(time.DateTime)
+		}
+		writeTabbed(tw, []string{o.Target, state, until, o.Reason, o.Created.AsTime().Local().Format(time.DateTime)})
+	}
+	return tw.Flush()
+}
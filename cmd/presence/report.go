@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/history"
+)
+
+type (
+	Report struct {
+		Since      string        `help:"Summarize transitions at or after TIME (default midnight a week ago)." placeholder:"TIME"`
+		Until      string        `default:"now" help:"Summarize transitions before TIME." placeholder:"TIME"`
+		FlapWindow time.Duration `default:"5m" help:"Count changing back within this long as flapping."`
+		Zone       string        `help:"Summarize the household and devices of this zone rather than those of the configuration."`
+		Format     string        `default:"text" enum:"text,json" help:"Output format (${enum})."`
+	}
+)
+
+// Run prints occupancy statistics of the household, or that of a zone, and
+// each person. Logs go to standard error so that the output can be
+// redirected.
+func (r *Report) Run(cli *CLI) error {
+	ctx := log.Context(context.Background(), log.WithOutput(os.Stderr))
+	since, until, err := r.period(wClock.Now().Truncate(time.Second))
+	if err != nil {
+		log.Fatal(ctx, err, log.KV{K: "msg", V: "invalid period"})
+	}
+
+	// Earlier transitions are needed for the state at the start of the
+	// period.
+	events := queryHistory(ctx, cli, history.Filter{Zone: r.Zone, Until: until})
+	report := history.NewReport(events, r.Zone, since, until, r.FlapWindow)
+
+	if r.Format == "json" {
+		e := json.NewEncoder(os.Stdout)
+		e.SetEscapeHTML(false)
+		e.SetIndent("", "  ")
+		return e.Encode(report)
+	}
+	return writeReport(os.Stdout, report)
+}
+
+func (r *Report) period(now time.Time) (since, until time.Time, err error) {
+	if r.Since == "" {
+		since, _ = history.ParseTime("today", now)
+		since = since.AddDate(0, 0, -7)
+	} else if since, err = history.ParseTime(r.Since, now); err != nil {
+		return since, until, fmt.Errorf("since: %w", err)
+	}
+	if until, err = history.ParseTime(r.Until, now); err != nil {
+		return since, until, fmt.Errorf("until: %w", err)
+	}
+	if now.Before(until) {
+		until = now
+	}
+	if !since.Before(until) {
+		return since, until, fmt.Errorf("since (%v) is not before until (%v)", since.Format(time.DateTime), until.Format(time.DateTime))
+	}
+	return since, until, nil
+}
+
+func writeReport(w io.Writer, r *history.Report) error {
+	if r.Zone != "" {
+		_, _ = fmt.Fprintf(w, "Presence in %v from %v to %v\n\n", r.Zone, r.Since.Format(time.DateTime), r.Until.Format(time.DateTime))
+	} else {
+		_, _ = fmt.Fprintf(w, "Presence from %v to %v\n\n", r.Since.Format(time.DateTime), r.Until.Format(time.DateTime))
+	}
+
+	stats := append([]history.Stats{r.Household}, r.People...)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeTabbed(tw, []string{"NAME", "OCCUPANCY", "PRESENT", "OBSERVED", "ARRIVALS", "TYPICAL ARRIVAL", "DEPARTURES", "TYPICAL DEPARTURE", "FLAPS"})
+	for _, s := range stats {
+		writeTabbed(tw, []string{
+			s.Name,
+			fmt.Sprintf("%.1f%%", s.Occupancy),
+			roundDuration(s.Present),
+			roundDuration(s.Observed),
+			fmt.Sprint(s.Arrivals),
+			s.TypicalArrival,
+			fmt.Sprint(s.Departures),
+			s.TypicalDeparture,
+			fmt.Sprint(s.Flaps),
+		})
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = io.WriteString(w, "\n")
+	writeTabbed(tw, []string{"NAME", "LONGEST ABSENCE", "START", "END"})
+	for _, s := range stats {
+		for _, a := range s.LongestAbsences {
+			end := a.End.Format(time.DateTime)
+			if a.Ongoing {
+				end = "ongoing"
+			}
+			writeTabbed(tw, []string{s.Name, roundDuration(a.Duration), a.Start.Format(time.DateTime), end})
+		}
+	}
+	return tw.Flush()
+}
+
+// roundDuration returns the duration to the minute.
+func roundDuration(d history.Duration) string {
+	return time.Duration(d).Round(time.Minute).String()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"goa.design/clue/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"douglasthrift.net/presence"
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+	"douglasthrift.net/presence/rpc"
+)
+
+type (
+	// rpcServer serves the gRPC control API.
+	rpcServer struct {
+		server *grpc.Server
+	}
+
+	// rpcClient are the flags of commands which use the gRPC control API of
+	// a running daemon.
+	rpcClient struct {
+		Server     string `env:"PRESENCE_SERVER" help:"Use the gRPC control API at ADDRESS (see detect --rpc-listen)." placeholder:"ADDRESS" required:""`
+		ServerCA   string `help:"Connect over TLS, verifying the server with the CA certificates in FILE." name:"server-ca" placeholder:"FILE" type:"existingfile"`
+		ClientCert string `help:"Connect over TLS, presenting the certificate in FILE." placeholder:"FILE" type:"existingfile"`
+		ClientKey  string `help:"Connect over TLS, presenting the key in FILE." placeholder:"FILE" type:"existingfile"`
+	}
+)
+
+func (d *Detect) newRPCServer(ctx context.Context, runner *presence.Runner, reload rpc.Reloader) (*rpcServer, error) {
+	var tlsConfig *tls.Config
+	switch {
+	case d.RPCCert != "" || d.RPCKey != "":
+		if d.RPCCert == "" || d.RPCKey == "" {
+			return nil, fmt.Errorf("--rpc-cert and --rpc-key must be used together")
+		}
+		var err error
+		tlsConfig, err = rpc.TLSConfig(d.RPCCert, d.RPCKey, d.RPCCA)
+		if err != nil {
+			return nil, err
+		}
+	case d.RPCCA != "":
+		return nil, fmt.Errorf("--rpc-client-ca requires --rpc-cert and --rpc-key")
+	}
+
+	l, err := net.Listen("tcp", d.RPCListen)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &rpcServer{server: rpc.NewGRPCServer(rpc.NewServer(runner, reload), tlsConfig)}
+	go func() {
+		err := s.server.Serve(l)
+		if err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error serving gRPC"})
+		}
+	}()
+	log.Print(ctx, log.KV{K: "msg", V: "serving gRPC"}, log.KV{K: "address", V: l.Addr()},
+		log.KV{K: "TLS", V: tlsConfig != nil}, log.KV{K: "mutual TLS", V: d.RPCCA != ""})
+
+	return s, nil
+}
+
+func (s *rpcServer) Close() error {
+	s.server.Stop()
+	return nil
+}
+
+// client returns a client of the gRPC control API, which uses TLS when any
+// of the TLS flags are set.
+func (c *rpcClient) client() (presencev1.PresenceServiceClient, func() error, error) {
+	creds := insecure.NewCredentials()
+	switch {
+	case c.ClientCert != "" || c.ClientKey != "":
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, nil, fmt.Errorf("--client-cert and --client-key must be used together")
+		}
+		fallthrough
+	case c.ServerCA != "":
+		tlsConfig, err := rpc.ClientTLSConfig(c.ServerCA, c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(c.Server, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, nil, err
+	}
+	return presencev1.NewPresenceServiceClient(conn), conn.Close, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence"
+	"douglasthrift.net/presence/history"
+	"douglasthrift.net/presence/web"
+)
+
+type (
+	// statusServer serves the detector status and last reload result and
+	// the recent history as JSON over HTTP, its changes as Server-Sent
+	// Events and a dashboard of them.
+	statusServer struct {
+		detector   presence.Detector
+		lastReload atomic.Pointer[presence.ReloadResult]
+		server     *http.Server
+	}
+
+	statusResponse struct {
+		*presence.Status
+		LastReload *presence.ReloadResult `json:"last_reload,omitempty"`
+	}
+)
+
+func newStatusServer(ctx context.Context, addr string, runner *presence.Runner, keepalive time.Duration) (*statusServer, error) {
+	if keepalive <= 0 {
+		return nil, fmt.Errorf("non-positive keepalive (%v)", keepalive)
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &statusServer{detector: runner.Detector()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.Handle("GET /events", web.NewEvents(s.detector, wClock, keepalive))
+	mux.Handle("GET /history", web.NewHistory(func() history.History { return runner.Runtime().History }))
+	mux.Handle("GET /", web.NewDashboard())
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	go func() {
+		err := s.server.Serve(l)
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error serving status"})
+		}
+	}()
+	log.Print(ctx, log.KV{K: "msg", V: "serving status"}, log.KV{K: "address", V: l.Addr()})
+
+	return s, nil
+}
+
+func (s *statusServer) Reloaded(result *presence.ReloadResult) {
+	s.lastReload.Store(result)
+}
+
+func (s *statusServer) Close() error {
+	return s.server.Close()
+}
+
+func (s *statusServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(&statusResponse{
+		Status:     s.detector.Status(),
+		LastReload: s.lastReload.Load(),
+	})
+	if err != nil {
+		log.Error(r.Context(), err, log.KV{K: "msg", V: "error encoding status"})
+	}
+}
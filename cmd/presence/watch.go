@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"goa.design/clue/log"
+)
+
+type (
+	// watcher notifies on C after files matching glob patterns, or the
+	// files their symbolic links resolve to, have changed and no further
+	// changes have occurred for the delay.
+	watcher struct {
+		C       <-chan struct{}
+		c       chan struct{}
+		fsw     *fsnotify.Watcher
+		delay   time.Duration
+		watches chan watch
+		closing chan struct{}
+
+		// The rest are only used by the goroutine running the watcher once
+		// it has started.
+		patterns []string
+		// targets maps the files matching the patterns to the files they
+		// resolve to, which change when a symbolic link to them is swapped
+		// (e.g. the ..data link of a Kubernetes volume).
+		targets map[string]string
+		dirs    map[string]bool
+	}
+
+	watch struct {
+		patterns []string
+		err      chan<- error
+	}
+)
+
+func newWatcher(ctx context.Context, delay time.Duration, patterns ...string) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	c := make(chan struct{}, 1)
+	w := &watcher{
+		C:       c,
+		c:       c,
+		fsw:     fsw,
+		delay:   delay,
+		watches: make(chan watch),
+		closing: make(chan struct{}),
+		dirs:    make(map[string]bool),
+	}
+
+	if err := w.watch(ctx, patterns); err != nil {
+		_ = fsw.Close()
+		return nil, err
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+// Watch replaces the watched patterns.
+func (w *watcher) Watch(ctx context.Context, patterns ...string) error {
+	errc := make(chan error, 1)
+	select {
+	case w.watches <- watch{patterns: patterns, err: errc}:
+		return <-errc
+	case <-w.closing:
+		return nil
+	}
+}
+
+func (w *watcher) Close() error {
+	close(w.closing)
+	return w.fsw.Close()
+}
+
+// watch replaces the watched patterns and the directories watched for them.
+func (w *watcher) watch(ctx context.Context, patterns []string) error {
+	ps := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		if p == "" {
+			continue
+		}
+
+		p, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, p)
+	}
+
+	w.patterns, w.targets = ps, resolve(ps)
+	return w.watchDirs(ctx)
+}
+
+// watchDirs watches the directories containing the patterns and their
+// targets since editors and secret managers often replace files rather than
+// writing them in place, and stops watching those which are no longer
+// needed. Directories which do not exist are skipped.
+func (w *watcher) watchDirs(ctx context.Context) error {
+	dirs := make(map[string]bool, len(w.patterns)+len(w.targets))
+	for _, p := range w.patterns {
+		dirs[filepath.Dir(p)] = true
+	}
+	for _, t := range w.targets {
+		dirs[filepath.Dir(t)] = true
+	}
+
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+
+		err := w.fsw.Add(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			log.Debug(ctx, log.KV{K: "msg", V: "not watching nonexistent directory"}, log.KV{K: "dir", V: dir})
+			delete(dirs, dir)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for dir := range w.dirs {
+		if dirs[dir] {
+			continue
+		}
+
+		// Directories which have been removed are no longer watched.
+		if err := w.fsw.Remove(dir); err != nil {
+			log.Debug(ctx, log.KV{K: "msg", V: "error no longer watching directory"}, log.KV{K: "dir", V: dir}, log.KV{K: "err", V: err})
+		}
+	}
+	w.dirs = dirs
+	return nil
+}
+
+func (w *watcher) run(ctx context.Context) {
+	var (
+		timer = time.NewTimer(w.delay)
+		event fsnotify.Event
+		ok    bool
+		err   error
+	)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok = <-w.fsw.Events:
+			if !ok {
+				return
+			}
+
+			if w.changed(ctx, event) {
+				log.Debug(ctx, log.KV{K: "msg", V: "watched file changed"}, log.KV{K: "file", V: event.Name}, log.KV{K: "op", V: event.Op})
+				timer.Reset(w.delay)
+			}
+		case err, ok = <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+
+			log.Error(ctx, err, log.KV{K: "msg", V: "error watching files"})
+		case <-timer.C:
+			select {
+			case w.c <- struct{}{}:
+			default:
+			}
+		case watch := <-w.watches:
+			watch.err <- w.watch(ctx, watch.patterns)
+		case <-w.closing:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// changed returns whether the file of the event is watched, either by
+// matching the patterns or being a target of the files which do, or whether
+// the targets have changed. A directory of the patterns being created is a
+// change too, since it was not watched for files to be added to it.
+func (w *watcher) changed(ctx context.Context, event fsnotify.Event) bool {
+	name := event.Name
+	if event.Has(fsnotify.Create) && w.patternDir(name) {
+		// A directory which was removed and created again needs to be
+		// watched again.
+		delete(w.dirs, name)
+		w.targets = resolve(w.patterns)
+		if err := w.watchDirs(ctx); err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error watching files"})
+		}
+		return true
+	}
+
+	if targets := resolve(w.patterns); !maps.Equal(targets, w.targets) {
+		w.targets = targets
+		if err := w.watchDirs(ctx); err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error watching files"})
+		}
+		return true
+	}
+
+	if match(w.patterns, name) {
+		return true
+	}
+	for _, t := range w.targets {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// patternDir returns whether name is the directory of any of the patterns.
+func (w *watcher) patternDir(name string) bool {
+	for _, p := range w.patterns {
+		if filepath.Dir(p) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the files matching the patterns mapped to the files their
+// symbolic links resolve to. Files which cannot be resolved are skipped.
+func resolve(patterns []string) map[string]string {
+	targets := make(map[string]string)
+	for _, p := range patterns {
+		names, _ := filepath.Glob(p)
+		for _, name := range names {
+			if t, err := filepath.EvalSymlinks(name); err == nil {
+				targets[name] = t
+			}
+		}
+	}
+	return targets
+}
+
+func match(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goa.design/clue/log"
+)
+
+const watchDelay = 10 * time.Millisecond
+
+func TestWatcher(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	dir := t.TempDir()
+	config := filepath.Join(dir, "presence.yml")
+	require.NoError(t, os.WriteFile(config, []byte("interval: 1m\n"), 0o644))
+
+	w, err := newWatcher(ctx, watchDelay, config, "")
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, w.Close()) }()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yml"), nil, 0o644))
+	assertNotNotified(t, w)
+
+	require.NoError(t, os.WriteFile(config, []byte("interval: 2m\n"), 0o644))
+	assertNotified(t, w)
+
+	// Replacing the file rather than writing it is also a change.
+	replacement := filepath.Join(dir, ".presence.yml.tmp")
+	require.NoError(t, os.WriteFile(replacement, []byte("interval: 3m\n"), 0o644))
+	require.NoError(t, os.Rename(replacement, config))
+	assertNotified(t, w)
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	var (
+		dir      = t.TempDir()
+		confd    = filepath.Join(dir, "presence.d")
+		config   = filepath.Join(dir, "presence.yml")
+		fragment = filepath.Join(confd, "devices.yml")
+	)
+	require.NoError(t, os.Mkdir(confd, 0o755))
+
+	w, err := newWatcher(ctx, watchDelay, config, filepath.Join(confd, "*.yml"))
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, w.Close()) }()
+	assert.ElementsMatch(t, []string{dir, confd}, w.fsw.WatchList())
+
+	require.NoError(t, os.WriteFile(fragment, nil, 0o644))
+	assertNotified(t, w)
+
+	// Directories are no longer watched once no patterns need them.
+	for range 3 {
+		require.NoError(t, w.Watch(ctx, config))
+	}
+	assert.Equal(t, []string{dir}, w.fsw.WatchList())
+
+	require.NoError(t, os.WriteFile(fragment, []byte("mac_addresses: []\n"), 0o644))
+	assertNotNotified(t, w)
+
+	require.NoError(t, w.Watch(ctx, config, filepath.Join(confd, "*.yml")))
+	assert.ElementsMatch(t, []string{dir, confd}, w.fsw.WatchList())
+}
+
+func TestWatcher_NewDirectory(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	var (
+		dir    = t.TempDir()
+		confd  = filepath.Join(dir, "presence.d")
+		config = filepath.Join(dir, "presence.yml")
+	)
+
+	w, err := newWatcher(ctx, watchDelay, config, filepath.Join(confd, "*.yml"))
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, w.Close()) }()
+	assert.Equal(t, []string{dir}, w.fsw.WatchList())
+
+	// A directory of the patterns which did not exist is watched once it
+	// is created.
+	require.NoError(t, os.Mkdir(confd, 0o755))
+	assertNotified(t, w)
+	assert.ElementsMatch(t, []string{dir, confd}, w.fsw.WatchList())
+
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "devices.yml"), nil, 0o644))
+	assertNotified(t, w)
+
+	// And again when it is removed and created again.
+	require.NoError(t, os.RemoveAll(confd))
+	assertNotified(t, w)
+	require.NoError(t, os.Mkdir(confd, 0o755))
+	assertNotified(t, w)
+	require.NoError(t, os.WriteFile(filepath.Join(confd, "devices.yml"), nil, 0o644))
+	assertNotified(t, w)
+}
+
+func TestWatcher_SymlinkSwap(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	dir := t.TempDir()
+
+	// Lay out the files the way a Kubernetes config map or secret volume
+	// does: the file is a link through the ..data link to a directory of
+	// the current version.
+	version := func(name, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "presence.yml"), []byte(content), 0o644))
+	}
+	version("..2024_01_01", "interval: 1m\n")
+	require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(dir, "..data")))
+	config := filepath.Join(dir, "presence.yml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "presence.yml"), config))
+
+	w, err := newWatcher(ctx, watchDelay, config)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, w.Close()) }()
+	assert.ElementsMatch(t, []string{dir, filepath.Join(dir, "..2024_01_01")}, w.fsw.WatchList())
+
+	version("..2024_01_02", "interval: 2m\n")
+	require.NoError(t, os.Symlink("..2024_01_02", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	assertNotified(t, w)
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "..2024_01_01")))
+
+	// Only the new version is watched and other files are still ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.yml"), nil, 0o644))
+	assertNotNotified(t, w)
+	assert.ElementsMatch(t, []string{dir, filepath.Join(dir, "..2024_01_02")}, w.fsw.WatchList())
+}
+
+func assertNotified(t *testing.T, w *watcher) {
+	t.Helper()
+	select {
+	case <-w.C:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "watcher did not notify")
+	}
+}
+
+func assertNotNotified(t *testing.T, w *watcher) {
+	t.Helper()
+	select {
+	case <-w.C:
+		assert.Fail(t, "watcher notified")
+	case <-time.After(10 * watchDelay):
+	}
+}
@@ -6,45 +6,213 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	"goa.design/clue/log"
-	"gopkg.in/yaml.v3"
 
+	"douglasthrift.net/presence/history"
+	"douglasthrift.net/presence/ifttt"
+	"douglasthrift.net/presence/neighbors"
 	"douglasthrift.net/presence/wrap"
 )
 
 type (
 	Config struct {
-		Interval time.Duration `yaml:"interval"`
+		// Include are glob patterns of config fragments, relative to the
+		// config file, which are merged into it before those in its config
+		// directory (see [ConfigDir]). Lists in fragments are appended and
+		// everything else replaced.
+		Include  []string      `toml:"include" yaml:"include"`
+		Interval time.Duration `toml:"interval" yaml:"interval"`
 		// RetriggerAfter is the duration after a state change to trigger
-		// an IFTTT webhook if no further changes occur. A zero value
-		// disables the delayed trigger (default behavior).
-		RetriggerAfter time.Duration `yaml:"retrigger_after"`
-		Interfaces     []string      `yaml:"interfaces"`
-		MACAddresses   []string      `yaml:"mac_addresses"`
-		PingCount      uint          `yaml:"ping_count"`
-		IFTTT          IFTTT         `yaml:"ifttt"`
+		// an IFTTT webhook if no further changes occur, and then again
+		// every RetriggerAfter. A zero value disables the delayed trigger
+		// (default behavior).
+		//
+		// Deprecated: RetriggerAfter is the same as Heartbeat.Every.
+		RetriggerAfter time.Duration `toml:"retrigger_after" yaml:"retrigger_after"`
+		// Heartbeat retriggers events while the household state, and
+		// optionally the state of each person, holds.
+		Heartbeat Heartbeat `toml:"heartbeat" yaml:"heartbeat"`
+		// Flapping is when the household or a device is considered to be
+		// flapping. Notifications of its changes are suppressed until it
+		// settles.
+		Flapping Flapping `toml:"flapping" yaml:"flapping"`
+		// Interfaces are the network interfaces to detect presence on by
+		// name or pattern. Patterns are matched on every detection so that
+		// interfaces coming up or going away are picked up without a
+		// restart. Empty matches every interface.
+		Interfaces []Interface `toml:"interfaces" yaml:"interfaces"`
+		// ExcludeLinks are the kinds of links (loopback, point_to_point or
+		// down) skipped by interface patterns, or none.
+		ExcludeLinks []string `toml:"exclude_links" yaml:"exclude_links"`
+		// MACAddresses are the devices to detect.
+		MACAddresses []Device `toml:"mac_addresses" yaml:"mac_addresses"`
+		PingCount    uint     `toml:"ping_count" yaml:"ping_count"`
+		IFTTT        IFTTT    `toml:"ifttt" yaml:"ifttt"`
+		History      History  `toml:"history" yaml:"history"`
+		// OverridesFile is the JSON file manual overrides of the state of
+		// devices and people are saved to so that they are kept across
+		// restarts. An empty value keeps them in memory only.
+		OverridesFile string `toml:"overrides_file" yaml:"overrides_file"`
+		// Zones are further households (e.g. a rental unit on its own
+		// VLAN) detected alongside this one from the same dump of the
+		// neighbor table on every detection.
+		Zones []Zone `toml:"zones" yaml:"zones"`
+	}
+
+	// Zone is a further household with its own interfaces, devices, IFTTT
+	// events, heartbeat and flapping detection. Unset events, heartbeat
+	// and flapping are those of the config, as are its interval, ping
+	// count, IFTTT key, history and overrides file.
+	Zone struct {
+		// Name identifies the zone in logs, notifications, the status and
+		// the history.
+		Name         string      `toml:"name" yaml:"name"`
+		Interfaces   []Interface `toml:"interfaces" yaml:"interfaces"`
+		MACAddresses []Device    `toml:"mac_addresses" yaml:"mac_addresses"`
+		IFTTT        ZoneIFTTT   `toml:"ifttt" yaml:"ifttt"`
+		Heartbeat    Heartbeat   `toml:"heartbeat" yaml:"heartbeat"`
+		Flapping     Flapping    `toml:"flapping" yaml:"flapping"`
+	}
+
+	// ZoneIFTTT is the IFTTT settings of a zone. An event with no event
+	// name has the name of the config's.
+	ZoneIFTTT struct {
+		Events Events `toml:"events" yaml:"events"`
+	}
+
+	// Interface is a network interface to detect presence on. In the config
+	// file it is either just the name or an object which also sets how
+	// neighbors on it are probed.
+	Interface struct {
+		// Name is the name of the network interface, a glob pattern (e.g.
+		// eth*) or a regular expression between slashes (e.g.
+		// /^wlan[0-9]+$/).
+		Name string `toml:"name" yaml:"name"`
+		// PingCount overrides Config.PingCount for this interface.
+		PingCount uint `toml:"ping_count" yaml:"ping_count"`
+		// Probe is how neighbors are confirmed to be present: arping (the
+		// default), ping or none to trust the neighbor table.
+		Probe string `toml:"probe" yaml:"probe"`
+		// AllowedIPs are the IP addresses or CIDR ranges of the neighbors
+		// which are probed. Empty allows all of them.
+		AllowedIPs []string `toml:"allowed_ips" yaml:"allowed_ips"`
+		// Timeout limits how long each probe may take. A neighbor whose
+		// probe times out is absent. A zero value disables the limit.
+		Timeout time.Duration `toml:"timeout" yaml:"timeout"`
+	}
+
+	// Device is a device to detect. In the config file it is either just its
+	// MAC address or an object which also describes it.
+	Device struct {
+		MACAddress string `toml:"mac_address" yaml:"mac_address"`
+		// Name is how the device is referred to in logs, notifications and
+		// the status (e.g. "Alice's Pixel") instead of its MAC address.
+		Name  string `toml:"name" yaml:"name"`
+		Owner string `toml:"owner" yaml:"owner"`
+		// Type is the kind of device (e.g. phone or laptop).
+		Type string `toml:"type" yaml:"type"`
+		// Icon is the name or URL of an icon for the device.
+		Icon string   `toml:"icon" yaml:"icon"`
+		Tags []string `toml:"tags" yaml:"tags"`
+		// Role is how the device counts toward the household being
+		// present: resident (the default), guest or ignored.
+		Role string `toml:"role" yaml:"role"`
+	}
+
+	// Heartbeat is how events are retriggered while a state holds.
+	Heartbeat struct {
+		// Every is how long after the state changed, and after each
+		// heartbeat, to trigger a heartbeat. A zero value disables
+		// heartbeats.
+		Every time.Duration `toml:"every" yaml:"every"`
+		// Count is how many heartbeats are triggered while the state
+		// holds. A zero value is unlimited.
+		Count uint `toml:"count" yaml:"count"`
+		// People is whether to also trigger heartbeats for each device
+		// owner while whether they are present holds.
+		People bool `toml:"people" yaml:"people"`
+	}
+
+	// Flapping is flapping detection. The household or a device starts
+	// flapping when it changes Transitions times within Window and stops
+	// once it has not changed for Window.
+	Flapping struct {
+		// Transitions is how many changes within Window are flapping. A
+		// zero value disables flapping detection.
+		Transitions uint          `toml:"transitions" yaml:"transitions"`
+		Window      time.Duration `toml:"window" yaml:"window"`
 	}
 
 	IFTTT struct {
-		BaseURL string `yaml:"base_url"`
-		Key     string `yaml:"key"`
-		Events  Events `yaml:"events"`
+		BaseURL string `toml:"base_url" yaml:"base_url"`
+		// Key is the IFTTT webhooks key. Environment variables referenced
+		// as ${VAR} or $VAR are expanded.
+		Key string `toml:"key" yaml:"key"`
+		// KeyFile is the path of a file containing the IFTTT webhooks key
+		// (e.g. a systemd credential or Docker secret) used instead of
+		// Key. Environment variables in the path are expanded.
+		KeyFile string `toml:"key_file" yaml:"key_file"`
+		Events  Events `toml:"events" yaml:"events"`
+	}
+
+	// History is where device and household transitions are recorded.
+	History struct {
+		// File is the JSON Lines file transitions are appended to. An empty
+		// value disables the history.
+		File string `toml:"file" yaml:"file"`
+		// MaxAge is how long transitions are kept. A zero value keeps them
+		// forever.
+		MaxAge time.Duration `toml:"max_age" yaml:"max_age"`
+		// MaxEvents is how many transitions are kept. A zero value keeps
+		// all of them.
+		MaxEvents uint `toml:"max_events" yaml:"max_events"`
 	}
 
 	Events struct {
-		Present Event `yaml:"present"`
-		Absent  Event `yaml:"absent"`
+		Present Event `toml:"present" yaml:"present"`
+		Absent  Event `toml:"absent" yaml:"absent"`
+		// Flapping is triggered once when the household or devices start
+		// flapping. It is not triggered when it has no event name.
+		Flapping Event `toml:"flapping" yaml:"flapping"`
+		// Heartbeat is triggered for heartbeats. Present or Absent are
+		// triggered instead when it has no event name.
+		Heartbeat Event `toml:"heartbeat" yaml:"heartbeat"`
 	}
 
+	// Event is an IFTTT event. Its values are templates evaluated with
+	// [ifttt.Data] when the event is triggered.
 	Event struct {
-		Event  string `yaml:"event"`
-		Value1 string `yaml:"value1"`
-		Value2 string `yaml:"value2"`
-		Value3 string `yaml:"value3"`
+		Event     string    `toml:"event" yaml:"event"`
+		Value1    string    `toml:"value1" yaml:"value1"`
+		Value2    string    `toml:"value2" yaml:"value2"`
+		Value3    string    `toml:"value3" yaml:"value3"`
+		RateLimit RateLimit `toml:"rate_limit" yaml:"rate_limit"`
+	}
+
+	// RateLimit limits how often an IFTTT event is triggered. Triggers
+	// which are held back are retried until the latest state is delivered.
+	// Zero fields are unlimited.
+	RateLimit struct {
+		// Events is how many times the event may be triggered per Per.
+		Events uint          `toml:"events" yaml:"events"`
+		Per    time.Duration `toml:"per" yaml:"per"`
+		// MinInterval is how long after the event was triggered it may not
+		// be triggered again with identical values.
+		MinInterval time.Duration `toml:"min_interval" yaml:"min_interval"`
+	}
+
+	// ConfigChange is a difference in a field between two configs.
+	ConfigChange struct {
+		// Field is the dotted path of the field (e.g. "ifttt.base_url").
+		Field string `json:"field"`
+		Old   any    `json:"old"`
+		New   any    `json:"new"`
 	}
 )
 
@@ -56,6 +224,10 @@ const (
 
 var (
 	eventName = regexp.MustCompile("^[_a-zA-Z]+$")
+
+	secretFields = map[string]bool{
+		"ifttt.key": true,
+	}
 )
 
 func ParseConfig(name string, wNet wrap.Net) (*Config, error) {
@@ -63,109 +235,438 @@ func ParseConfig(name string, wNet wrap.Net) (*Config, error) {
 }
 
 func ParseConfigWithContext(ctx context.Context, name string, wNet wrap.Net) (*Config, error) {
-	f, err := os.Open(name)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = f.Close() }()
+	return ParseConfigWithOverrides(ctx, name, nil, wNet)
+}
 
-	d := yaml.NewDecoder(f)
-	d.KnownFields(true)
+// ParseConfigWithOverrides parses the config file (YAML, or JSON or TOML by
+// its .json or .toml extension) and its fragments with values from PRESENCE_*
+// environment variables and then the overrides taking precedence over those
+// in the file. An empty name skips the file so that the config can come
+// entirely from the environment and overrides.
+func ParseConfigWithOverrides(ctx context.Context, name string, overrides ConfigOverrides, wNet wrap.Net) (*Config, error) {
+	var (
+		c   = &Config{}
+		src = sources{}
+	)
+	if name != "" {
+		if err := loadConfig(name, c, src); err != nil {
+			return nil, err
+		}
+	}
 
-	c := &Config{}
-	err = d.Decode(c)
+	err := src.override(c, overrides, os.LookupEnv)
 	if err != nil {
 		return nil, err
 	}
 
 	if c.Interval < 0 {
-		return nil, fmt.Errorf("negative interval (%v)", c.Interval)
+		return nil, src.cite(name, fmt.Errorf("negative interval (%v)", c.Interval), "interval")
 	} else if c.Interval == 0 {
 		c.Interval = 30 * time.Second
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "interval"}, log.KV{K: "value", V: c.Interval})
+	log.Print(ctx, log.KV{K: "msg", V: "interval"}, log.KV{K: "value", V: c.Interval}, log.KV{K: "source", V: src.of("interval")})
 
 	if c.RetriggerAfter < 0 {
-		return nil, fmt.Errorf("negative retrigger_after (%v)", c.RetriggerAfter)
+		return nil, src.cite(name, fmt.Errorf("negative retrigger_after (%v)", c.RetriggerAfter), "retrigger_after")
 	}
 	// RetriggerAfter default is zero (disabled)
-	log.Print(ctx, log.KV{K: "msg", V: "retrigger after"}, log.KV{K: "value", V: c.RetriggerAfter})
+	log.Print(ctx, log.KV{K: "msg", V: "retrigger after"}, log.KV{K: "value", V: c.RetriggerAfter},
+		log.KV{K: "source", V: src.of("retrigger_after")})
+
+	switch {
+	case c.Heartbeat.Every < 0:
+		return nil, src.cite(name, fmt.Errorf("negative heartbeat every (%v)", c.Heartbeat.Every), "heartbeat.every")
+	case c.Heartbeat.Every != 0 && c.RetriggerAfter != 0:
+		return nil, src.cite(name, fmt.Errorf("both retrigger_after and heartbeat every"), "retrigger_after", "heartbeat.every")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "heartbeat"},
+		log.KV{K: "every", V: c.Heartbeat.Every}, log.KV{K: "every source", V: src.of("heartbeat.every")},
+		log.KV{K: "count", V: c.Heartbeat.Count}, log.KV{K: "count source", V: src.of("heartbeat.count")},
+		log.KV{K: "people", V: c.Heartbeat.People}, log.KV{K: "people source", V: src.of("heartbeat.people")})
+
+	if err = c.Flapping.validate(); err != nil {
+		return nil, src.cite(name, err, "flapping")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "flapping"},
+		log.KV{K: "transitions", V: c.Flapping.Transitions}, log.KV{K: "transitions source", V: src.of("flapping.transitions")},
+		log.KV{K: "window", V: c.Flapping.Window}, log.KV{K: "window source", V: src.of("flapping.window")})
 
 	if len(c.Interfaces) == 0 {
-		ifs, err := wNet.Interfaces()
+		c.Interfaces = []Interface{{Name: "*"}}
+	}
+	if err = validateInterfaces(ctx, c.Interfaces, wNet); err != nil {
+		return nil, src.cite(name, err, "interfaces")
+	}
+
+	if len(c.ExcludeLinks) == 0 {
+		c.ExcludeLinks = neighbors.LinkNames()
+	} else if _, err = neighbors.ParseLinks(c.ExcludeLinks); err != nil {
+		return nil, src.cite(name, fmt.Errorf("exclude_links: %w", err), "exclude_links")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "exclude links"}, log.KV{K: "value", V: c.ExcludeLinks}, log.KV{K: "source", V: src.of("exclude_links")})
+
+	ifs := c.NeighborsInterfaces()
+	var all []net.Interface
+	if ifs.HasPatterns() {
+		all, err = wNet.Interfaces()
 		if err != nil {
 			return nil, err
 		}
+	}
+	matched := make([]string, 0, len(all))
+	for n := range ifs.Resolve(all) {
+		matched = append(matched, n)
+	}
+	sort.Strings(matched)
 
-		c.Interfaces = make([]string, 0, len(ifs))
-		for _, i := range ifs {
-			c.Interfaces = append(c.Interfaces, i.Name)
-		}
-	} else {
-		for _, i := range c.Interfaces {
-			_, err = wNet.InterfaceByName(i)
-			if err != nil {
-				return nil, fmt.Errorf("interface %v: %w", i, err)
-			}
+	patterns := make([]string, 0, len(c.Interfaces))
+	for _, i := range c.Interfaces {
+		patterns = append(patterns, i.Name)
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "interfaces"}, log.KV{K: "value", V: patterns}, log.KV{K: "matched", V: matched},
+		log.KV{K: "source", V: src.of("interfaces")})
+	for _, i := range c.Interfaces {
+		if i.PingCount != 0 || i.Probe != "" || len(i.AllowedIPs) != 0 || i.Timeout != 0 {
+			log.Print(ctx, log.KV{K: "msg", V: "interface"}, log.KV{K: "name", V: i.Name}, log.KV{K: "ping count", V: i.PingCount},
+				log.KV{K: "probe", V: i.Probe}, log.KV{K: "allowed IPs", V: i.AllowedIPs}, log.KV{K: "timeout", V: i.Timeout})
 		}
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "interfaces"}, log.KV{K: "value", V: c.Interfaces})
 
-	if len(c.MACAddresses) == 0 {
+	// The household of the config may only group zones.
+	if len(c.MACAddresses) == 0 && len(c.Zones) == 0 {
 		return nil, fmt.Errorf("no MAC addresses")
 	}
-	as := make(map[string]bool, len(c.MACAddresses))
-	for i, a := range c.MACAddresses {
-		hw, err := net.ParseMAC(a)
-		if err != nil {
-			return nil, err
-		}
-
-		a = hw.String()
-		if as[a] {
-			return nil, fmt.Errorf("duplicate MAC address (%v)", a)
+	if err = normalizeDevices(c.MACAddresses); err != nil {
+		return nil, src.cite(name, err, "mac_addresses")
+	}
+	addresses := make([]string, 0, len(c.MACAddresses))
+	for _, d := range c.MACAddresses {
+		addresses = append(addresses, d.MACAddress)
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "MAC addresses"}, log.KV{K: "value", V: addresses},
+		log.KV{K: "source", V: src.of("mac_addresses")})
+	for _, d := range c.MACAddresses {
+		if d.Name != "" || d.Owner != "" || d.Type != "" || d.Icon != "" || len(d.Tags) != 0 || d.Role != "" {
+			log.Print(ctx, log.KV{K: "msg", V: "device"}, log.KV{K: "MAC address", V: d.MACAddress}, log.KV{K: "name", V: d.Name},
+				log.KV{K: "owner", V: d.Owner}, log.KV{K: "type", V: d.Type}, log.KV{K: "icon", V: d.Icon}, log.KV{K: "tags", V: d.Tags},
+				log.KV{K: "role", V: d.Role})
 		}
-		as[a] = true
-		c.MACAddresses[i] = a
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "MAC addresses"}, log.KV{K: "value", V: c.MACAddresses})
 
 	if c.PingCount == 0 {
 		c.PingCount = 1
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "ping count"}, log.KV{K: "value", V: c.PingCount})
+	log.Print(ctx, log.KV{K: "msg", V: "ping count"}, log.KV{K: "value", V: c.PingCount}, log.KV{K: "source", V: src.of("ping_count")})
 
 	if c.IFTTT.BaseURL == "" {
 		c.IFTTT.BaseURL = defaultBaseURL
 	} else if _, err := url.Parse(c.IFTTT.BaseURL); err != nil {
-		return nil, fmt.Errorf("IFTTT base URL: %w", err)
+		return nil, src.cite(name, fmt.Errorf("IFTTT base URL: %w", err), "ifttt.base_url")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "IFTTT base URL"}, log.KV{K: "value", V: c.IFTTT.BaseURL},
+		log.KV{K: "source", V: src.of("ifttt.base_url")})
+
+	source := src.of("ifttt.key")
+	if c.IFTTT.Key != "" && c.IFTTT.KeyFile != "" {
+		// Whichever was set with the higher precedence wins, so that e.g.
+		// PRESENCE_IFTTT_KEY replaces a key_file from the config file.
+		switch key, keyFile := src.precedence("ifttt.key"), src.precedence("ifttt.key_file"); {
+		case key > keyFile:
+			c.IFTTT.KeyFile = ""
+		case key < keyFile:
+			c.IFTTT.Key = ""
+		default:
+			return nil, src.cite(name, fmt.Errorf("both IFTTT key and key_file"), "ifttt.key", "ifttt.key_file")
+		}
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "IFTTT base URL"}, log.KV{K: "value", V: c.IFTTT.BaseURL})
+	if c.IFTTT.KeyFile != "" {
+		c.IFTTT.KeyFile, err = expandEnv(c.IFTTT.KeyFile)
+		if err != nil {
+			return nil, src.cite(name, fmt.Errorf("IFTTT key_file: %w", err), "ifttt.key_file")
+		}
 
-	if c.IFTTT.Key == "" {
-		return nil, fmt.Errorf("no IFTTT key")
+		b, err := os.ReadFile(c.IFTTT.KeyFile)
+		if err != nil {
+			return nil, src.cite(name, fmt.Errorf("IFTTT key_file: %w", err), "ifttt.key_file")
+		}
+
+		c.IFTTT.Key = strings.TrimSpace(string(b))
+		if c.IFTTT.Key == "" {
+			return nil, src.cite(name, fmt.Errorf("IFTTT key_file: empty file (%v)", c.IFTTT.KeyFile), "ifttt.key_file")
+		}
+		source = src.of("ifttt.key_file")
+	} else {
+		c.IFTTT.Key, err = expandEnv(c.IFTTT.Key)
+		if err != nil {
+			return nil, src.cite(name, fmt.Errorf("IFTTT key: %w", err), "ifttt.key")
+		}
+
+		if c.IFTTT.Key == "" {
+			return nil, fmt.Errorf("no IFTTT key")
+		}
 	}
-	log.Print(ctx, log.KV{K: "msg", V: "IFTTT key"}, log.KV{K: "value", V: strings.Repeat("*", len(c.IFTTT.Key))})
+	log.Print(ctx, log.KV{K: "msg", V: "IFTTT key"}, log.KV{K: "value", V: strings.Repeat("*", len(c.IFTTT.Key))},
+		log.KV{K: "source", V: source}, log.KV{K: "file", V: c.IFTTT.KeyFile})
 
 	if c.IFTTT.Events.Present.Event == "" {
 		c.IFTTT.Events.Present.Event = defaultPresentEvent
-	} else if !eventName.MatchString(c.IFTTT.Events.Present.Event) {
-		return nil, fmt.Errorf("invalid IFTTT present event name: %#v", c.IFTTT.Events.Present.Event)
+	}
+	if err = c.IFTTT.Events.Present.validate("present"); err != nil {
+		return nil, src.cite(name, err, "ifttt.events.present")
 	}
 	log.Print(ctx, log.KV{K: "msg", V: "IFTTT present event"}, log.KV{K: "value", V: c.IFTTT.Events.Present.Event},
-		log.KV{K: "value1", V: c.IFTTT.Events.Present.Value1},
-		log.KV{K: "value2", V: c.IFTTT.Events.Present.Value2},
-		log.KV{K: "value3", V: c.IFTTT.Events.Present.Value3})
+		log.KV{K: "source", V: src.of("ifttt.events.present.event")},
+		log.KV{K: "value1", V: c.IFTTT.Events.Present.Value1}, log.KV{K: "value1 source", V: src.of("ifttt.events.present.value1")},
+		log.KV{K: "value2", V: c.IFTTT.Events.Present.Value2}, log.KV{K: "value2 source", V: src.of("ifttt.events.present.value2")},
+		log.KV{K: "value3", V: c.IFTTT.Events.Present.Value3}, log.KV{K: "value3 source", V: src.of("ifttt.events.present.value3")})
+	c.IFTTT.Events.Present.RateLimit.log(ctx, "IFTTT present event rate limit", src, "ifttt.events.present.rate_limit")
 
 	if c.IFTTT.Events.Absent.Event == "" {
 		c.IFTTT.Events.Absent.Event = defaultAbsentEvent
-	} else if !eventName.MatchString(c.IFTTT.Events.Absent.Event) {
-		return nil, fmt.Errorf("invalid IFTTT absent event name: %#v", c.IFTTT.Events.Absent.Event)
+	}
+	if err = c.IFTTT.Events.Absent.validate("absent"); err != nil {
+		return nil, src.cite(name, err, "ifttt.events.absent")
 	}
 	log.Print(ctx, log.KV{K: "msg", V: "IFTTT absent event"}, log.KV{K: "value", V: c.IFTTT.Events.Absent.Event},
-		log.KV{K: "value1", V: c.IFTTT.Events.Absent.Value1},
-		log.KV{K: "value2", V: c.IFTTT.Events.Absent.Value2},
-		log.KV{K: "value3", V: c.IFTTT.Events.Absent.Value3})
+		log.KV{K: "source", V: src.of("ifttt.events.absent.event")},
+		log.KV{K: "value1", V: c.IFTTT.Events.Absent.Value1}, log.KV{K: "value1 source", V: src.of("ifttt.events.absent.value1")},
+		log.KV{K: "value2", V: c.IFTTT.Events.Absent.Value2}, log.KV{K: "value2 source", V: src.of("ifttt.events.absent.value2")},
+		log.KV{K: "value3", V: c.IFTTT.Events.Absent.Value3}, log.KV{K: "value3 source", V: src.of("ifttt.events.absent.value3")})
+	c.IFTTT.Events.Absent.RateLimit.log(ctx, "IFTTT absent event rate limit", src, "ifttt.events.absent.rate_limit")
+
+	if err = c.IFTTT.Events.Flapping.validate("flapping"); err != nil {
+		return nil, src.cite(name, err, "ifttt.events.flapping")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "IFTTT flapping event"}, log.KV{K: "value", V: c.IFTTT.Events.Flapping.Event},
+		log.KV{K: "source", V: src.of("ifttt.events.flapping.event")},
+		log.KV{K: "value1", V: c.IFTTT.Events.Flapping.Value1}, log.KV{K: "value1 source", V: src.of("ifttt.events.flapping.value1")},
+		log.KV{K: "value2", V: c.IFTTT.Events.Flapping.Value2}, log.KV{K: "value2 source", V: src.of("ifttt.events.flapping.value2")},
+		log.KV{K: "value3", V: c.IFTTT.Events.Flapping.Value3}, log.KV{K: "value3 source", V: src.of("ifttt.events.flapping.value3")})
+	c.IFTTT.Events.Flapping.RateLimit.log(ctx, "IFTTT flapping event rate limit", src, "ifttt.events.flapping.rate_limit")
+
+	if err = c.IFTTT.Events.Heartbeat.validate("heartbeat"); err != nil {
+		return nil, src.cite(name, err, "ifttt.events.heartbeat")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "IFTTT heartbeat event"}, log.KV{K: "value", V: c.IFTTT.Events.Heartbeat.Event},
+		log.KV{K: "source", V: src.of("ifttt.events.heartbeat.event")},
+		log.KV{K: "value1", V: c.IFTTT.Events.Heartbeat.Value1}, log.KV{K: "value1 source", V: src.of("ifttt.events.heartbeat.value1")},
+		log.KV{K: "value2", V: c.IFTTT.Events.Heartbeat.Value2}, log.KV{K: "value2 source", V: src.of("ifttt.events.heartbeat.value2")},
+		log.KV{K: "value3", V: c.IFTTT.Events.Heartbeat.Value3}, log.KV{K: "value3 source", V: src.of("ifttt.events.heartbeat.value3")})
+	c.IFTTT.Events.Heartbeat.RateLimit.log(ctx, "IFTTT heartbeat event rate limit", src, "ifttt.events.heartbeat.rate_limit")
+	if c.Heartbeat.People && c.IFTTT.Events.Heartbeat.Event == "" {
+		log.Warn(ctx, log.KV{K: "msg", V: "no IFTTT heartbeat event for heartbeat people"})
+	}
+
+	if c.History.MaxAge < 0 {
+		return nil, src.cite(name, fmt.Errorf("negative history max_age (%v)", c.History.MaxAge), "history.max_age")
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "history"}, log.KV{K: "file", V: c.History.File}, log.KV{K: "file source", V: src.of("history.file")},
+		log.KV{K: "max age", V: c.History.MaxAge}, log.KV{K: "max age source", V: src.of("history.max_age")},
+		log.KV{K: "max events", V: c.History.MaxEvents}, log.KV{K: "max events source", V: src.of("history.max_events")})
+	log.Print(ctx, log.KV{K: "msg", V: "overrides file"}, log.KV{K: "value", V: c.OverridesFile}, log.KV{K: "source", V: src.of("overrides_file")})
+
+	if err = c.validateZones(ctx, wNet); err != nil {
+		return nil, src.cite(name, err, "zones")
+	}
+	for _, z := range c.Zones {
+		log.Print(ctx, log.KV{K: "msg", V: "zone"}, log.KV{K: "name", V: z.Name}, log.KV{K: "interfaces", V: z.interfaceNames()},
+			log.KV{K: "MAC addresses", V: z.addresses()}, log.KV{K: "source", V: src.of("zones")})
+	}
 
 	return c, nil
 }
+
+// validateInterfaces checks that the interfaces have unique names and valid
+// settings, warning about those which are not patterns and do not exist.
+func validateInterfaces(ctx context.Context, interfaces []Interface, wNet wrap.Net) error {
+	names := make(map[string]bool, len(interfaces))
+	for index, i := range interfaces {
+		if i.Name == "" {
+			return &elementError{index, fmt.Errorf("interface with no name")}
+		}
+		if names[i.Name] {
+			return &elementError{index, fmt.Errorf("duplicate interface (%v)", i.Name)}
+		}
+		names[i.Name] = true
+
+		// Like patterns matching nothing yet, interfaces which do not exist
+		// yet (e.g. USB network adapters or VLANs) are used once they do.
+		if !neighbors.IsPattern(i.Name) {
+			if _, err := wNet.InterfaceByName(i.Name); err != nil {
+				log.Warn(ctx, log.KV{K: "msg", V: "interface not found"}, log.KV{K: "interface", V: i.Name}, log.KV{K: "err", V: err})
+			}
+		}
+
+		if err := i.validate(); err != nil {
+			return &elementError{index, fmt.Errorf("interface %v: %w", i.Name, err)}
+		}
+	}
+	return nil
+}
+
+// normalizeDevices checks the devices and puts their MAC addresses in
+// canonical form.
+func normalizeDevices(devices []Device) error {
+	as := make(map[string]bool, len(devices))
+	for i, d := range devices {
+		if d.MACAddress == "" {
+			return &elementError{i, fmt.Errorf("device with no MAC address")}
+		}
+
+		hw, err := net.ParseMAC(d.MACAddress)
+		if err != nil {
+			return &elementError{i, err}
+		}
+
+		a := hw.String()
+		if as[a] {
+			return &elementError{i, fmt.Errorf("duplicate MAC address (%v)", a)}
+		}
+		if _, err = ParseRole(d.Role); err != nil {
+			return &elementError{i, fmt.Errorf("device %v: %w", a, err)}
+		}
+		as[a] = true
+		devices[i].MACAddress = a
+	}
+	return nil
+}
+
+func (f Flapping) validate() error {
+	switch {
+	case f.Window < 0:
+		return fmt.Errorf("negative flapping window (%v)", f.Window)
+	case f.Transitions == 1:
+		return fmt.Errorf("flapping transitions less than 2")
+	case f.Transitions != 0 && f.Window == 0:
+		return fmt.Errorf("flapping transitions with no window")
+	}
+	return nil
+}
+
+// validate checks the name, values and rate limit of the event, which is
+// the kind of event (e.g. present) in errors.
+func (e Event) validate(kind string) error {
+	if e.Event != "" && !eventName.MatchString(e.Event) {
+		return fmt.Errorf("invalid IFTTT %v event name: %#v", kind, e.Event)
+	}
+	if _, err := e.Values().Parse(); err != nil {
+		return fmt.Errorf("IFTTT %v event values: %w", kind, err)
+	}
+	if err := e.RateLimit.validate(); err != nil {
+		return fmt.Errorf("IFTTT %v event rate_limit: %w", kind, err)
+	}
+	return nil
+}
+
+// expandEnv replaces ${VAR} or $VAR in s with the value of the environment
+// variable, returning an error if any of the variables are not set.
+func expandEnv(s string) (string, error) {
+	var unset []string
+	s = os.Expand(s, func(key string) string {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			unset = append(unset, key)
+		}
+		return v
+	})
+	if len(unset) != 0 {
+		return "", fmt.Errorf("environment variable %v not set", strings.Join(unset, ", "))
+	}
+	return s, nil
+}
+
+// IFTTT returns the IFTTT events.
+func (e Events) IFTTT() ifttt.Events {
+	return ifttt.Events{
+		Present:   e.Present.IFTTT(),
+		Absent:    e.Absent.IFTTT(),
+		Flapping:  e.Flapping.IFTTT(),
+		Heartbeat: e.Heartbeat.IFTTT(),
+	}
+}
+
+// heartbeatPolicy returns the heartbeat policy of the validated config,
+// which is every RetriggerAfter when it is set.
+func (c *Config) heartbeatPolicy() Heartbeat {
+	if c.RetriggerAfter > 0 {
+		return Heartbeat{Every: c.RetriggerAfter}
+	}
+	return c.Heartbeat
+}
+
+// IFTTT returns the IFTTT event.
+func (e Event) IFTTT() ifttt.Event {
+	return ifttt.Event{Name: e.Event, Values: e.Values(), Limit: e.RateLimit.Limit()}
+}
+
+// Limit returns the IFTTT rate limit.
+func (r RateLimit) Limit() ifttt.Limit {
+	return ifttt.Limit{
+		Events:      r.Events,
+		Per:         r.Per,
+		MinInterval: r.MinInterval,
+	}
+}
+
+func (r RateLimit) validate() error {
+	switch {
+	case r.Per < 0:
+		return fmt.Errorf("negative per (%v)", r.Per)
+	case r.MinInterval < 0:
+		return fmt.Errorf("negative min_interval (%v)", r.MinInterval)
+	case r.Events != 0 && r.Per == 0:
+		return fmt.Errorf("events with no per")
+	case r.Events == 0 && r.Per != 0:
+		return fmt.Errorf("per with no events")
+	}
+	return nil
+}
+
+func (r RateLimit) log(ctx context.Context, msg string, src sources, path string) {
+	if r == (RateLimit{}) {
+		return
+	}
+	log.Print(ctx, log.KV{K: "msg", V: msg},
+		log.KV{K: "events", V: r.Events}, log.KV{K: "events source", V: src.of(path + ".events")},
+		log.KV{K: "per", V: r.Per}, log.KV{K: "per source", V: src.of(path + ".per")},
+		log.KV{K: "min interval", V: r.MinInterval}, log.KV{K: "min interval source", V: src.of(path + ".min_interval")})
+}
+
+// Values returns the value templates of the event.
+func (e Event) Values() ifttt.Values {
+	return ifttt.Values{
+		Value1: e.Value1,
+		Value2: e.Value2,
+		Value3: e.Value3,
+	}
+}
+
+// DiffConfig returns the fields which differ between the old and new config
+// with the values of secret fields redacted.
+func DiffConfig(old, new *Config) []ConfigChange {
+	var (
+		ofs     = configFields("", reflect.ValueOf(old).Elem(), nil)
+		nfs     = configFields("", reflect.ValueOf(new).Elem(), nil)
+		changes []ConfigChange
+	)
+	for i, of := range ofs {
+		o, n := of.value, nfs[i].value
+		if !reflect.DeepEqual(o.Interface(), n.Interface()) {
+			change := ConfigChange{Field: of.path, Old: o.Interface(), New: n.Interface()}
+			if secretFields[of.path] {
+				change.Old = strings.Repeat("*", o.Len())
+				change.New = strings.Repeat("*", n.Len())
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Retention returns the limits of how much history is kept.
+func (h History) Retention() history.Retention {
+	return history.Retention{
+		MaxAge:    h.MaxAge,
+		MaxEvents: h.MaxEvents,
+	}
+}
@@ -3,12 +3,14 @@ package presence
 import (
 	"fmt"
 	"net"
+	"net/netip"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"douglasthrift.net/presence/neighbors"
 	mockwrap "douglasthrift.net/presence/wrap/mocks"
 )
 
@@ -35,8 +37,9 @@ func TestParseConfig(t *testing.T) {
 			config: &Config{
 				Interval:       1 * time.Minute,
 				RetriggerAfter: 24 * time.Hour,
-				Interfaces:     []string{"eth0", "eth1"},
-				MACAddresses:   []string{"00:00:00:00:00:0a", "00:00:00:00:00:0b"},
+				Interfaces:     []Interface{{Name: "eth0"}, {Name: "eth1"}},
+				ExcludeLinks:   neighbors.LinkNames(),
+				MACAddresses:   []Device{{MACAddress: "00:00:00:00:00:0a"}, {MACAddress: "00:00:00:00:00:0b"}},
 				PingCount:      5,
 				IFTTT: IFTTT{
 					BaseURL: "https://example.com",
@@ -63,14 +66,19 @@ func TestParseConfig(t *testing.T) {
 			file: "defaults.yml",
 			setup: func(t *testing.T, wNet *mockwrap.Net) {
 				wNet.AddInterfaces(func() ([]net.Interface, error) {
-					return []net.Interface{{Name: "eth0"}, {Name: "eth1"}, {Name: "lo"}}, nil
+					return []net.Interface{
+						{Name: "eth0", Flags: net.FlagUp},
+						{Name: "eth1", Flags: net.FlagUp},
+						{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+					}, nil
 				})
 			},
 			config: &Config{
 				Interval:       30 * time.Second,
 				RetriggerAfter: 0,
-				Interfaces:     []string{"eth0", "eth1", "lo"},
-				MACAddresses:   []string{"00:00:00:00:00:01", "00:00:00:00:00:02"},
+				Interfaces:     []Interface{{Name: "*"}},
+				ExcludeLinks:   neighbors.LinkNames(),
+				MACAddresses:   []Device{{MACAddress: "00:00:00:00:00:01"}, {MACAddress: "00:00:00:00:00:02"}},
 				PingCount:      1,
 				IFTTT: IFTTT{
 					BaseURL: defaultBaseURL,
@@ -123,15 +131,44 @@ func TestParseConfig(t *testing.T) {
 			err: "no network interfaces",
 		},
 		{
-			name: "error getting interface by name",
+			name: "interface not found",
 			file: "success.yml",
 			setup: func(t *testing.T, wNet *mockwrap.Net) {
 				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
 					assert.Equal(t, "eth0", name)
 					return nil, fmt.Errorf("no such network interface")
 				})
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth1", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:       1 * time.Minute,
+				RetriggerAfter: 24 * time.Hour,
+				Interfaces:     []Interface{{Name: "eth0"}, {Name: "eth1"}},
+				ExcludeLinks:   neighbors.LinkNames(),
+				MACAddresses:   []Device{{MACAddress: "00:00:00:00:00:0a"}, {MACAddress: "00:00:00:00:00:0b"}},
+				PingCount:      5,
+				IFTTT: IFTTT{
+					BaseURL: "https://example.com",
+					Key:     "abcdef123456",
+					Events: Events{
+						Present: Event{
+							Event:  "event_presence_detected",
+							Value1: "event_presence_detected_value1",
+							Value2: "event_presence_detected_value2",
+							Value3: "event_presence_detected_value3",
+						},
+						Absent: Event{
+							Event:  "event_absence_detected",
+							Value1: "event_absence_detected_value1",
+							Value2: "event_absence_detected_value2",
+							Value3: "event_absence_detected_value3",
+						},
+					},
+				},
 			},
-			err: "interface eth0: no such network interface",
 		},
 		{
 			name: "no MAC addresses",
@@ -199,6 +236,260 @@ func TestParseConfig(t *testing.T) {
 			},
 			err: `invalid IFTTT absent event name: "^"`,
 		},
+		{
+			name: "invalid IFTTT present event values",
+			file: "invalid_ifttt_present_event_values.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: `IFTTT present event values: template: value1:1: unclosed action`,
+		},
+		{
+			name: "invalid IFTTT absent event values",
+			file: "invalid_ifttt_absent_event_values.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: `IFTTT absent event values: template: value2:1: unexpected EOF`,
+		},
+		{
+			name: "flapping",
+			file: "flapping.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Flapping:     Flapping{Transitions: 4, Window: 10 * time.Minute},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "xyz7890!@#",
+					Events: Events{
+						Present:  Event{Event: defaultPresentEvent},
+						Absent:   Event{Event: defaultAbsentEvent},
+						Flapping: Event{Event: "presence_flapping", Value1: "{{range .Changed}}{{.}} {{end}}"},
+					},
+				},
+			},
+		},
+		{
+			name: "negative flapping window",
+			file: "negative_flapping_window.yml",
+			err:  "negative flapping window (-1ns)",
+		},
+		{
+			name: "invalid flapping transitions",
+			file: "invalid_flapping_transitions.yml",
+			err:  "flapping transitions less than 2",
+		},
+		{
+			name: "no flapping window",
+			file: "no_flapping_window.yml",
+			err:  "flapping transitions with no window",
+		},
+		{
+			name: "invalid IFTTT flapping event name",
+			file: "invalid_ifttt_flapping_event_name.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: `invalid IFTTT flapping event name: "flapping!"`,
+		},
+		{
+			name: "invalid IFTTT flapping event values",
+			file: "invalid_ifttt_flapping_event_values.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "IFTTT flapping event values: template: value3:1: unclosed action",
+		},
+		{
+			name: "heartbeat",
+			file: "heartbeat.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Heartbeat:    Heartbeat{Every: time.Hour, Count: 3, People: true},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "xyz7890!@#",
+					Events: Events{
+						Present:   Event{Event: defaultPresentEvent},
+						Absent:    Event{Event: defaultAbsentEvent},
+						Heartbeat: Event{Event: "presence_heartbeat", Value1: "{{if .Owner}}{{.Owner}}{{else}}household{{end}}"},
+					},
+				},
+			},
+		},
+		{
+			name: "negative heartbeat every",
+			file: "negative_heartbeat_every.yml",
+			err:  "negative heartbeat every (-1ns)",
+		},
+		{
+			name: "retrigger_after and heartbeat every",
+			file: "retrigger_after_and_heartbeat.yml",
+			err:  "both retrigger_after and heartbeat every",
+		},
+		{
+			name: "invalid IFTTT heartbeat event name",
+			file: "invalid_ifttt_heartbeat_event_name.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: `invalid IFTTT heartbeat event name: "heartbeat!"`,
+		},
+		{
+			name: "rate limit",
+			file: "rate_limit.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "xyz7890!@#",
+					Events: Events{
+						Present: Event{
+							Event:     defaultPresentEvent,
+							RateLimit: RateLimit{Events: 10, Per: time.Hour, MinInterval: 5 * time.Minute},
+						},
+						Absent: Event{
+							Event:     defaultAbsentEvent,
+							RateLimit: RateLimit{MinInterval: 5 * time.Minute},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "rate limit with no per",
+			file: "rate_limit_no_per.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "IFTTT absent event rate_limit: events with no per",
+		},
+		{
+			name: "negative rate limit min_interval",
+			file: "negative_rate_limit_min_interval.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "IFTTT present event rate_limit: negative min_interval (-1s)",
+		},
+		{
+			name: "history",
+			file: "history.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "xyz7890!@#",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+				History: History{
+					File:      "/var/db/presence/history.jsonl",
+					MaxAge:    720 * time.Hour,
+					MaxEvents: 10000,
+				},
+			},
+		},
+		{
+			name: "overrides file",
+			file: "overrides_file.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01", Owner: "Alice"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "xyz7890!@#",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+				OverridesFile: "/var/db/presence/overrides.json",
+			},
+		},
+		{
+			name: "negative history max_age",
+			file: "negative_history_max_age.yml",
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "negative history max_age (-1ns)",
+		},
 	}
 
 	for _, tc := range cases {
@@ -224,3 +515,576 @@ func TestParseConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestParseConfig_IFTTTKey(t *testing.T) {
+	t.Setenv("PRESENCE_TEST_IFTTT_KEY", "abcdef123456")
+	t.Setenv("PRESENCE_TEST_DIR", "tests")
+
+	cases := []struct {
+		name, file, key, keyFile, err string
+	}{
+		{
+			name: "environment variable",
+			file: "env_ifttt_key.yml",
+			key:  "abcdef123456",
+		},
+		{
+			name: "unset environment variable",
+			file: "unset_env_ifttt_key.yml",
+			err:  "IFTTT key: environment variable PRESENCE_TEST_UNSET not set",
+		},
+		{
+			name:    "key file",
+			file:    "ifttt_key_file.yml",
+			key:     "abcdef123456",
+			keyFile: "tests/ifttt_key",
+		},
+		{
+			name:    "key file environment variable",
+			file:    "env_ifttt_key_file.yml",
+			key:     "abcdef123456",
+			keyFile: "tests/ifttt_key",
+		},
+		{
+			name: "nonexistent key file",
+			file: "nonexistent_ifttt_key_file.yml",
+			err:  "IFTTT key_file: open tests/nonexistent_ifttt_key: no such file or directory",
+		},
+		{
+			name: "empty key file",
+			file: "empty_ifttt_key_file.yml",
+			err:  "IFTTT key_file: empty file (tests/empty_ifttt_key)",
+		},
+		{
+			name: "key and key file",
+			file: "ifttt_key_and_key_file.yml",
+			err:  "both IFTTT key and key_file",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			wNet := mockwrap.NewNet(t)
+			wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+				assert.Equal("eth0", name)
+				return &net.Interface{}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.key, c.IFTTT.Key)
+				assert.Equal(tc.keyFile, c.IFTTT.KeyFile)
+			}
+
+			assert.False(wNet.HasMore(), "missing expected net calls")
+		})
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	old := &Config{
+		Interval:     30 * time.Second,
+		Interfaces:   []Interface{{Name: "eth0"}},
+		MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+		PingCount:    1,
+		IFTTT: IFTTT{
+			BaseURL: defaultBaseURL,
+			Key:     "abc",
+			Events: Events{
+				Present: Event{Event: defaultPresentEvent},
+				Absent:  Event{Event: defaultAbsentEvent},
+			},
+		},
+	}
+
+	t.Run("same", func(t *testing.T) {
+		assert.Empty(t, DiffConfig(old, old))
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		new := *old
+		new.Interval = time.Minute
+		new.MACAddresses = []Device{{MACAddress: "00:00:00:00:00:01"}, {MACAddress: "00:00:00:00:00:02", Name: "Pixel"}}
+		new.IFTTT.Key = "abcdef"
+		new.IFTTT.Events.Absent.Value1 = "{{.Hostname}}"
+
+		assert.Equal(t, []ConfigChange{
+			{Field: "interval", Old: 30 * time.Second, New: time.Minute},
+			{Field: "mac_addresses", Old: []Device{{MACAddress: "00:00:00:00:00:01"}}, New: []Device{{MACAddress: "00:00:00:00:00:01"}, {MACAddress: "00:00:00:00:00:02", Name: "Pixel"}}},
+			{Field: "ifttt.key", Old: "***", New: "******"},
+			{Field: "ifttt.events.absent.value1", Old: "", New: "{{.Hostname}}"},
+		}, DiffConfig(old, &new))
+	})
+}
+
+func TestParseConfig_Formats(t *testing.T) {
+	cases := []struct {
+		name, file, err string
+	}{
+		{
+			name: "JSON",
+			file: "success.json",
+		},
+		{
+			name: "TOML",
+			file: "success.toml",
+		},
+		{
+			name: "JSON unknown field",
+			file: "unknown_field.json",
+			err:  "yaml: unmarshal errors:\n  line 1: field pingcount not found in type presence.Config",
+		},
+		{
+			name: "TOML unknown fields",
+			file: "unknown_field.toml",
+			err:  "toml: unknown fields: pingcount, ifttt.secret",
+		},
+		{
+			name: "YAML in JSON",
+			file: "yaml_in.json",
+			err:  "json: invalid character 'm' looking for beginning of value",
+		},
+		{
+			name: "invalid TOML",
+			file: "invalid.toml",
+			err:  "toml: line 1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			var exp *Config
+			if tc.err == "" {
+				wNet := mockwrap.NewNet(t)
+				wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+					return &net.Interface{}, nil
+				})
+
+				var err error
+				exp, err = ParseConfig(filepath.Join("tests", "success.yml"), wNet)
+				if !assert.NoError(err) {
+					return
+				}
+			}
+
+			wNet := mockwrap.NewNet(t)
+			wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+				return &net.Interface{}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.ErrorContains(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(exp, c)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Interfaces(t *testing.T) {
+	interfaces := []Interface{
+		{Name: "eth0"},
+		{Name: "eth1", PingCount: 3, Probe: "ping", AllowedIPs: []string{"192.168.1.0/24", "10.0.0.7"}, Timeout: 2 * time.Second},
+		{Name: "wlan0", Probe: "none"},
+	}
+
+	cases := []struct {
+		name, file string
+		interfaces []Interface
+		err        string
+	}{
+		{
+			name:       "YAML",
+			file:       "interfaces.yml",
+			interfaces: interfaces,
+		},
+		{
+			name:       "JSON",
+			file:       "interfaces.json",
+			interfaces: interfaces,
+		},
+		{
+			name:       "TOML",
+			file:       "interfaces.toml",
+			interfaces: interfaces,
+		},
+		{
+			name: "invalid probe",
+			file: "invalid_interface_probe.yml",
+			err:  `interface eth0: invalid probe "icmp"`,
+		},
+		{
+			name: "invalid allowed IPs",
+			file: "invalid_interface_allowed_ips.yml",
+			err:  `interface eth0: allowed IPs: invalid IP address or range "192.168.1"`,
+		},
+		{
+			name: "negative timeout",
+			file: "negative_interface_timeout.yml",
+			err:  "interface eth0: negative timeout (-1s)",
+		},
+		{
+			name: "unknown field",
+			file: "unknown_interface_field.yml",
+			err:  "yaml: unmarshal errors:\n  line 3: field subnet not found in type presence.Interface",
+		},
+		{
+			name: "TOML unknown field",
+			file: "unknown_interface_field.toml",
+			err:  "unknown interface fields: subnet",
+		},
+		{
+			name: "duplicate",
+			file: "duplicate_interface.yml",
+			err:  "duplicate interface (eth0)",
+		},
+		{
+			name: "no name",
+			file: "no_interface_name.yml",
+			err:  "interface with no name",
+		},
+		{
+			name:       "patterns",
+			file:       "interface_patterns.yml",
+			interfaces: []Interface{{Name: "eth0"}, {Name: "/^wlan[0-9]+$/"}, {Name: "usb*", Probe: "none"}},
+		},
+		{
+			name: "invalid pattern",
+			file: "invalid_interface_pattern.yml",
+			err:  `interface eth[: syntax error in pattern "eth["`,
+		},
+		{
+			name: "invalid regular expression",
+			file: "invalid_interface_regexp.yml",
+			err:  "interface /eth(/: error parsing regexp: missing closing ): `eth(`",
+		},
+		{
+			name: "invalid exclude links",
+			file: "invalid_exclude_links.yml",
+			err:  `exclude_links: invalid link "bridge"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			wNet := mockwrap.NewNet(t)
+			wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+				return &net.Interface{}, nil
+			})
+			wNet.SetInterfaces(func() ([]net.Interface, error) {
+				return []net.Interface{{Name: "eth0", Flags: net.FlagUp}, {Name: "wlan0", Flags: net.FlagUp}}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.ErrorContains(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.interfaces, c.Interfaces)
+			}
+		})
+	}
+}
+
+func TestConfig_NeighborsInterfaces(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Config{
+		Interfaces: []Interface{
+			{Name: "eth0"},
+			{Name: "wlan*", PingCount: 3, Probe: "ping", AllowedIPs: []string{"192.168.1.0/24", "10.0.0.7"}, Timeout: 2 * time.Second},
+		},
+		ExcludeLinks: []string{"loopback", "down"},
+	}
+	assert.Equal(neighbors.Interfaces{
+		{Name: "eth0", Exclude: neighbors.LinkLoopback | neighbors.LinkDown, Probe: neighbors.ProbeARPing},
+		{
+			Name:       "wlan*",
+			Exclude:    neighbors.LinkLoopback | neighbors.LinkDown,
+			PingCount:  3,
+			Probe:      neighbors.ProbePing,
+			AllowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("10.0.0.7/32")},
+			Timeout:    2 * time.Second,
+		},
+	}, c.NeighborsInterfaces())
+}
+
+func TestParseConfig_Devices(t *testing.T) {
+	devices := []Device{
+		{MACAddress: "00:00:00:00:00:19"},
+		{
+			MACAddress: "00:00:00:00:00:1a",
+			Name:       "Alice's Pixel",
+			Owner:      "Alice",
+			Type:       "phone",
+			Icon:       "mdi:cellphone",
+			Tags:       []string{"family", "android"},
+		},
+		{MACAddress: "00:00:00:00:00:1b", Name: "Living Room TV", Role: "ignored"},
+	}
+
+	cases := []struct {
+		name, file string
+		devices    []Device
+		err        string
+	}{
+		{
+			name:    "YAML",
+			file:    "devices.yml",
+			devices: devices,
+		},
+		{
+			name:    "JSON",
+			file:    "devices.json",
+			devices: devices,
+		},
+		{
+			name:    "TOML",
+			file:    "devices.toml",
+			devices: devices,
+		},
+		{
+			name: "unknown field",
+			file: "unknown_device_field.yml",
+			err:  "yaml: unmarshal errors:\n  line 4: field person not found in type presence.Device",
+		},
+		{
+			name: "TOML unknown field",
+			file: "unknown_device_field.toml",
+			err:  "unknown device fields: person",
+		},
+		{
+			name: "no MAC address",
+			file: "no_device_mac_address.yml",
+			err:  "device with no MAC address",
+		},
+		{
+			name: "invalid role",
+			file: "invalid_device_role.yml",
+			err:  `device 00:00:00:00:00:19: invalid role "visitor"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			wNet := mockwrap.NewNet(t)
+			wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+				return &net.Interface{}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.ErrorContains(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.devices, c.MACAddresses)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Zones(t *testing.T) {
+	zones := []Zone{{
+		Name:         "rental",
+		Interfaces:   []Interface{{Name: "eth0.20"}},
+		MACAddresses: []Device{{MACAddress: "00:00:00:00:00:21"}, {MACAddress: "00:00:00:00:00:22", Owner: "Carol", Role: "guest"}},
+		IFTTT: ZoneIFTTT{
+			Events: Events{
+				Present: Event{Event: "rental_present"},
+				Absent:  Event{Value1: "{{.Zone}}"},
+			},
+		},
+		Flapping: Flapping{Transitions: 4, Window: 10 * time.Minute},
+	}}
+
+	cases := []struct {
+		name, file string
+		zones      []Zone
+		err        string
+	}{
+		{
+			name:  "YAML",
+			file:  "zones.yml",
+			zones: zones,
+		},
+		{
+			name:  "TOML",
+			file:  "zones.toml",
+			zones: zones,
+		},
+		{
+			name: "only zones",
+			file: "zones_only.yml",
+			zones: []Zone{{
+				Name:         "rental",
+				Interfaces:   []Interface{{Name: "eth0.20"}},
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:21"}},
+			}},
+		},
+		{
+			name: "no name",
+			file: "no_zone_name.yml",
+			err:  "zone with no name",
+		},
+		{
+			name: "invalid name",
+			file: "invalid_zone_name.yml",
+			err:  `invalid zone name: "rental unit"`,
+		},
+		{
+			name: "duplicate",
+			file: "duplicate_zone.yml",
+			err:  "duplicate zone (rental)",
+		},
+		{
+			name: "no interfaces",
+			file: "no_zone_interfaces.yml",
+			err:  "zone rental: no interfaces",
+		},
+		{
+			name: "no MAC addresses",
+			file: "no_zone_mac_addresses.yml",
+			err:  "zone rental: no MAC addresses",
+		},
+		{
+			name: "duplicate MAC address",
+			file: "duplicate_zone_mac_address.yml",
+			err:  "zone rental: duplicate MAC address (00:00:00:00:00:21)",
+		},
+		{
+			name: "invalid event name",
+			file: "invalid_zone_event_name.yml",
+			err:  `zone rental: invalid IFTTT absent event name: "rental-absent"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			wNet := mockwrap.NewNet(t)
+			wNet.SetInterfaces(func() ([]net.Interface, error) {
+				return []net.Interface{{Name: "eth0.20"}}, nil
+			})
+			wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+				return &net.Interface{}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.zones, c.Zones)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Include(t *testing.T) {
+	cases := []struct {
+		name, file string
+		config     *Config
+		err        string
+	}{
+		{
+			name: "merged",
+			file: "include/presence.yml",
+			config: &Config{
+				Include:      []string{"macs/*.yml"},
+				Interval:     2 * time.Minute,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}, {MACAddress: "00:00:00:00:00:02"}, {MACAddress: "00:00:00:00:00:03"}, {MACAddress: "00:00:00:00:00:04"}},
+				PingCount:    2,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "fragment",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent, Value1: "{{.Hostname}}"},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+			},
+		},
+		{
+			name: "duplicate MAC address",
+			file: "include_duplicate/presence.yml",
+			err:  "tests/include_duplicate/presence.d/macs.yml: duplicate MAC address (00:00:00:00:00:01) also in tests/include_duplicate/presence.yml",
+		},
+		{
+			name: "fragment error",
+			file: "include_error/presence.yml",
+			err:  "tests/include_error/presence.d/bad.yml: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `many` into uint",
+		},
+		{
+			name: "fragment invalid value",
+			file: "include_invalid/presence.yml",
+			err:  `tests/include_invalid/presence.d/events.yml: invalid IFTTT present event name: "present!"`,
+		},
+		{
+			name: "fragment invalid device",
+			file: "include_invalid_device/presence.yml",
+			err:  `tests/include_invalid_device/presence.d/devices.yml: device 00:00:00:00:00:03: invalid role "owner"`,
+		},
+		{
+			name: "invalid device with fragment",
+			file: "include_invalid_main/presence.yml",
+			err:  `device 00:00:00:00:00:01: invalid role "owner"`,
+		},
+		{
+			name: "nested include",
+			file: "include_nested/presence.yml",
+			err:  "tests/include_nested/presence.d/nested.yml: include is only allowed in tests/include_nested/presence.yml",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			wNet := mockwrap.NewNet(t)
+			wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+				return &net.Interface{}, nil
+			})
+
+			c, err := ParseConfig(filepath.Join("tests", tc.file), wNet)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.config, c)
+			}
+		})
+	}
+}
+
+func TestConfigPatterns(t *testing.T) {
+	assert.Equal(t, []string{
+		"etc/presence.yml",
+		"etc/macs/*.yml",
+		"/srv/presence/*.toml",
+		"etc/presence.d/*",
+		"/run/credentials/ifttt_key",
+	}, ConfigPatterns("etc/presence.yml", &Config{
+		Include: []string{"macs/*.yml", "/srv/presence/*.toml"},
+		IFTTT:   IFTTT{KeyFile: "/run/credentials/ifttt_key"},
+	}))
+}
@@ -2,12 +2,20 @@ package presence
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"goa.design/clue/log"
 
+	"douglasthrift.net/presence/history"
 	"douglasthrift.net/presence/ifttt"
 	"douglasthrift.net/presence/neighbors"
+	"douglasthrift.net/presence/wrap"
 )
 
 type (
@@ -15,53 +23,172 @@ type (
 		Detect(ctx context.Context) error
 		Config(config *Config)
 		Client(client ifttt.Client)
+		History(h history.History)
+		Runtime(rt *Runtime)
+		Status() *Status
+		// Subscribe returns a channel of the changes found by each
+		// detection from then on, which is closed once ctx is done. Changes
+		// are dropped rather than waited for when it is not kept up with.
+		Subscribe(ctx context.Context) <-chan Change
+		// SetOverride pins the presence of the devices targeted by the
+		// override from the next detection on, replacing any override of
+		// the same target. It is safe to call concurrently with Detect.
+		SetOverride(o StateOverride) error
+		// ClearOverride removes the override of the target from the next
+		// detection on, returning whether there was one. It is safe to call
+		// concurrently with Detect.
+		ClearOverride(target string) (bool, error)
+		// Overrides returns the overrides which have not expired. It is
+		// safe to call concurrently with Detect.
+		Overrides() ([]StateOverride, error)
+		// SetGuestMode sets whether guest devices count toward the
+		// household from the next detection on. It is safe to call
+		// concurrently with Detect.
+		SetGuestMode(enabled bool)
+		// GuestMode returns whether guest mode is enabled. It is safe to
+		// call concurrently with Detect.
+		GuestMode() bool
 	}
 
 	detector struct {
+		// zone is the name of the zone detected, or empty for the
+		// household of the config.
+		zone       string
 		config     *Config
 		arp        neighbors.ARP
 		interfaces neighbors.Interfaces
 		state      neighbors.State
 		states     neighbors.HardwareAddrStates
 		client     ifttt.Client
-		lastChange time.Time
+		history    history.History
+		clock      wrap.Clock
+		since      time.Time
+		// heartbeat and people track the heartbeats of the household and
+		// of each device owner.
+		heartbeat heartbeat
+		people    map[string]*person
+		// flap and flaps track whether the household and each device are
+		// flapping.
+		flap  flapper
+		flaps map[string]*flapper
+		// seen is when each device was last detected present.
+		seen map[string]time.Time
+		// overrides are the overrides of the state of devices and people
+		// and overridden the ones applied to each device by the last
+		// detection.
+		overrides  *stateOverrides
+		overridden map[string]StateOverride
+		// guestMode is whether guest devices count toward the household
+		// and guestModeApplied whether they did in the last detection.
+		guestMode        *atomic.Bool
+		guestModeApplied bool
+		// notified is whether an event has been triggered and
+		// notifiedPresent the household state it was triggered for.
+		notified, notifiedPresent bool
+		// pending is whether a change of the household was not notified
+		// because it was flapping or rate limited.
+		pending bool
+		// suppressed counts the triggers of each event held back by its
+		// rate limit.
+		suppressed map[string]uint
+		notifier   NotifierStatus
+		mu         sync.RWMutex
+		status     *Status
+		subs       *subscribers
+	}
+
+	// household is the state of the household when it is not simply
+	// whether any device is present: it is present when any device which
+	// counts toward it is, once overrides are applied, rather than as
+	// detected.
+	household struct {
+		neighbors.State
+		states neighbors.HardwareAddrStates
+		counts map[string]bool
 	}
 )
 
-func NewDetector(config *Config, arp neighbors.ARP, client ifttt.Client) Detector {
+func NewDetector(config *Config, arp neighbors.ARP, client ifttt.Client, clock wrap.Clock) Detector {
 	d := &detector{
-		arp:    arp,
-		state:  neighbors.NewState(),
-		states: make(neighbors.HardwareAddrStates, len(config.MACAddresses)),
-		client: client,
+		arp:        arp,
+		state:      neighbors.NewState(clock),
+		states:     make(neighbors.HardwareAddrStates, len(config.MACAddresses)),
+		client:     client,
+		clock:      clock,
+		people:     make(map[string]*person),
+		flaps:      make(map[string]*flapper, len(config.MACAddresses)),
+		seen:       make(map[string]time.Time, len(config.MACAddresses)),
+		overrides:  &stateOverrides{},
+		overridden: make(map[string]StateOverride),
+		guestMode:  &atomic.Bool{},
+		suppressed: make(map[string]uint),
+		status:     &Status{},
+		subs:       &subscribers{},
 	}
 	d.Config(config)
 	return d
 }
 
 func (d *detector) Detect(ctx context.Context) error {
-	log.Print(ctx, log.KV{K: "msg", V: "detecting presence"}, log.KV{K: "present", V: d.state.Present()})
-	err := d.arp.Present(ctx, d.interfaces, d.state, d.states)
+	defer d.updateStatus()
+
+	log.Print(ctx, log.KV{K: "msg", V: "detecting presence"}, log.KV{K: "zone", V: d.zone}, log.KV{K: "present", V: d.state.Present()})
+	guestMode := d.guestMode.Load()
+	state, states := d.detectStates(d.overrides.active(ctx, d.clock.Now()), guestMode)
+	err := d.arp.Present(ctx, d.interfaces, state, states)
 	if err != nil {
 		return err
 	}
+	guestModeChanged := guestMode != d.guestModeApplied
+	d.guestModeApplied = guestMode
 
 	for _, a := range d.config.MACAddresses {
-		state := d.states[a]
-		log.Print(ctx, log.KV{K: "msg", V: a}, log.KV{K: "present", V: state.Present()}, log.KV{K: "changed", V: state.Changed()})
+		state := d.states[a.MACAddress]
+		device := state.Device()
+		_, overridden := d.overridden[a.MACAddress]
+		log.Print(ctx, log.KV{K: "msg", V: device.Label(a.MACAddress)}, log.KV{K: "MAC address", V: a.MACAddress},
+			log.KV{K: "owner", V: device.Owner}, log.KV{K: "present", V: state.Present()}, log.KV{K: "changed", V: state.Changed()},
+			log.KV{K: "interface", V: state.Interface()}, log.KV{K: "role", V: a.role()}, log.KV{K: "overridden", V: overridden})
 	}
 
-	log.Print(ctx, log.KV{K: "msg", V: "detected presence"}, log.KV{K: "present", V: d.state.Present()}, log.KV{K: "changed", V: d.state.Changed()})
-	if d.state.Changed() {
-		if d.config.RetriggerAfter > 0 {
-			d.lastChange = time.Now()
+	now := d.clock.Now()
+	for _, a := range d.config.MACAddresses {
+		present := d.states[a.MACAddress].Present()
+		if state, ok := states[a.MACAddress].(*overriddenState); ok {
+			present = state.detected
 		}
+		if present {
+			d.seen[a.MACAddress] = now
+		}
+	}
+	d.record(ctx, now)
+	d.subs.publish(ctx, d.changes(now, guestModeChanged))
+	flapping := d.flapping(ctx, now)
+	d.updatePeople(now)
 
-		event, values, err := d.client.Trigger(ctx, d.state.Present())
+	log.Print(ctx, log.KV{K: "msg", V: "detected presence"}, log.KV{K: "zone", V: d.zone}, log.KV{K: "present", V: d.state.Present()}, log.KV{K: "changed", V: d.state.Changed()},
+		log.KV{K: "guest mode", V: guestMode}, log.KV{K: "flapping", V: flapping}, log.KV{K: "pending", V: d.pending})
+	if d.state.Changed() && flapping {
+		d.pending = true
+	}
+	if d.pending && d.notified && d.notifiedPresent == d.state.Present() {
+		// The state went back to the one which was last notified.
+		d.pending = false
+	} else if (d.state.Changed() || d.pending) && !flapping {
+		event, values, err := d.trigger(ctx, now, d.data(ctx, now))
+		if d.rateLimited(ctx, err) {
+			d.pending = true
+			return nil
+		}
 		if err != nil {
 			d.state.Reset()
 			return err
 		}
+		d.since = now
+		d.notified, d.notifiedPresent, d.pending = true, d.state.Present(), false
+		if d.config.heartbeatPolicy().Every > 0 {
+			d.heartbeat.reset(now)
+		}
 		log.Print(ctx, log.KV{K: "msg", V: "triggered IFTTT"}, log.KV{K: "event", V: event},
 			log.KV{K: "value1", V: values.Value1},
 			log.KV{K: "value2", V: values.Value2},
@@ -69,44 +196,377 @@ func (d *detector) Detect(ctx context.Context) error {
 		return nil
 	}
 
-	if d.config.RetriggerAfter > 0 && !d.lastChange.IsZero() {
-		if time.Since(d.lastChange) >= d.config.RetriggerAfter {
-			event, values, err := d.client.Trigger(ctx, d.state.Present())
-			if err != nil {
-				return err
+	if flapping || d.pending {
+		return nil
+	}
+	return d.heartbeats(ctx, now)
+}
+
+// detectStates returns the household and device states to detect into so
+// that the overrides replace the detected presence of the devices they
+// target and the household only follows the devices whose roles count
+// toward it in guest mode or not.
+func (d *detector) detectStates(overrides []StateOverride, guestMode bool) (neighbors.State, neighbors.HardwareAddrStates) {
+	clear(d.overridden)
+
+	var (
+		states = make(neighbors.HardwareAddrStates, len(d.states))
+		counts = make(map[string]bool, len(d.config.MACAddresses))
+		all    = true
+	)
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		counts[a.MACAddress] = a.role().Counts(guestMode)
+		all = all && counts[a.MACAddress]
+
+		o, ok := overrideFor(overrides, a.MACAddress, state.Device())
+		if !ok {
+			states[a.MACAddress] = state
+			continue
+		}
+		d.overridden[a.MACAddress] = o
+		states[a.MACAddress] = &overriddenState{State: state, present: o.Present}
+	}
+	if all && len(d.overridden) == 0 {
+		return d.state, d.states
+	}
+	return &household{State: d.state, states: states, counts: counts}, states
+}
+
+func (h *household) Set(bool) {
+	present := false
+	for hw, state := range h.states {
+		present = present || h.counts[hw] && state.Present()
+	}
+	h.State.Set(present)
+}
+
+// heartbeats triggers the heartbeats of the household and of each device
+// owner which are due. Failing to trigger a heartbeat of an owner is logged
+// rather than returned so that it does not hold up the others. Owners only
+// get heartbeats with a heartbeat event since the present or absent event
+// would report the household as such.
+func (d *detector) heartbeats(ctx context.Context, now time.Time) error {
+	policy := d.config.heartbeatPolicy()
+	if d.heartbeat.due(now, policy) {
+		data := d.data(ctx, now)
+		data.Heartbeat, data.Changed = true, nil
+
+		event, values, err := d.trigger(ctx, now, data)
+		if d.rateLimited(ctx, err) {
+			d.heartbeat.beat(now)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		d.heartbeat.beat(now)
+		log.Print(ctx, log.KV{K: "msg", V: "triggered IFTTT (heartbeat)"}, log.KV{K: "event", V: event},
+			log.KV{K: "count", V: d.heartbeat.count},
+			log.KV{K: "value1", V: values.Value1},
+			log.KV{K: "value2", V: values.Value2},
+			log.KV{K: "value3", V: values.Value3})
+	}
+
+	if !policy.People || d.config.IFTTT.Events.Heartbeat.Event == "" {
+		return nil
+	}
+	for _, owner := range slices.Sorted(maps.Keys(d.people)) {
+		p := d.people[owner]
+		if !p.heartbeat.due(now, policy) {
+			continue
+		}
+
+		data := d.data(ctx, now)
+		data.Heartbeat, data.Owner, data.Present, data.Changed = true, owner, p.present, nil
+
+		event, values, err := d.trigger(ctx, now, data)
+		if d.rateLimited(ctx, err) {
+			p.heartbeat.beat(now)
+			continue
+		}
+		if err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error triggering IFTTT heartbeat"}, log.KV{K: "owner", V: owner})
+			continue
+		}
+		p.heartbeat.beat(now)
+		log.Print(ctx, log.KV{K: "msg", V: "triggered IFTTT (heartbeat)"}, log.KV{K: "event", V: event},
+			log.KV{K: "owner", V: owner}, log.KV{K: "count", V: p.heartbeat.count},
+			log.KV{K: "value1", V: values.Value1},
+			log.KV{K: "value2", V: values.Value2},
+			log.KV{K: "value3", V: values.Value3})
+	}
+	return nil
+}
+
+// updatePeople updates whether each device owner is present, restarting
+// their heartbeats when it changes. Only devices which count toward the
+// household make their owners present.
+func (d *detector) updatePeople(now time.Time) {
+	present := make(map[string]bool, len(d.people))
+	for _, a := range d.config.MACAddresses {
+		if !a.role().Counts(d.guestModeApplied) {
+			continue
+		}
+		state := d.states[a.MACAddress]
+		if owner := state.Device().Owner; owner != "" {
+			present[owner] = present[owner] || state.Present()
+		}
+	}
+
+	for owner := range d.people {
+		if _, ok := present[owner]; !ok {
+			delete(d.people, owner)
+		}
+	}
+	for owner, ok := range present {
+		p := d.people[owner]
+		switch {
+		case p == nil:
+			p = &person{present: ok}
+			p.heartbeat.reset(now)
+			d.people[owner] = p
+		case p.present != ok:
+			p.present = ok
+			p.heartbeat.reset(now)
+		}
+	}
+}
+
+// trigger triggers the IFTTT event for the data at now, keeping track of
+// the health of the notifications.
+func (d *detector) trigger(ctx context.Context, now time.Time, data *ifttt.Data) (string, *ifttt.Values, error) {
+	event, values, err := d.client.Trigger(ctx, data)
+	var rle *ifttt.RateLimitError
+	switch {
+	case errors.As(err, &rle):
+		// Suppressed triggers are neither successes nor failures.
+	case err != nil:
+		d.notifier.LastError, d.notifier.LastFailed = err.Error(), now
+		d.notifier.Failures++
+	default:
+		d.notifier.LastTriggered, d.notifier.LastEvent = now, event
+		d.notifier.Failures = 0
+	}
+	return event, values, err
+}
+
+// rateLimited returns whether the trigger error is because of a rate limit,
+// counting and logging the suppressed trigger when it is.
+func (d *detector) rateLimited(ctx context.Context, err error) bool {
+	var rle *ifttt.RateLimitError
+	if !errors.As(err, &rle) {
+		return false
+	}
+
+	d.suppressed[rle.Event]++
+	log.Warn(ctx, log.KV{K: "msg", V: "suppressed IFTTT trigger"}, log.KV{K: "event", V: rle.Event},
+		log.KV{K: "identical", V: rle.Identical}, log.KV{K: "retry after", V: rle.RetryAfter},
+		log.KV{K: "suppressed", V: d.suppressed[rle.Event]})
+	return true
+}
+
+// flapping updates whether the household and each device are flapping and
+// warns once when any of them start to. It returns whether the household is
+// flapping, in which case its changes are not notified until it settles.
+func (d *detector) flapping(ctx context.Context, now time.Time) bool {
+	started := make(map[string]bool)
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		f := d.flaps[a.MACAddress]
+		label := state.Device().Label(a.MACAddress)
+		switch start, stop := f.update(state.Changed(), now, d.config.Flapping); {
+		case start:
+			started[a.MACAddress] = true
+			log.Warn(ctx, log.KV{K: "msg", V: "device flapping"}, log.KV{K: "device", V: label}, log.KV{K: "MAC address", V: a.MACAddress})
+		case stop:
+			log.Print(ctx, log.KV{K: "msg", V: "device stopped flapping"}, log.KV{K: "device", V: label}, log.KV{K: "MAC address", V: a.MACAddress})
+		}
+	}
+
+	start, stop := d.flap.update(d.state.Changed(), now, d.config.Flapping)
+	switch {
+	case start:
+		log.Warn(ctx, log.KV{K: "msg", V: "household flapping"}, log.KV{K: "present", V: d.state.Present()})
+	case stop:
+		log.Print(ctx, log.KV{K: "msg", V: "household stopped flapping"}, log.KV{K: "present", V: d.state.Present()})
+	}
+
+	if (start || len(started) != 0) && d.config.IFTTT.Events.Flapping.Event != "" {
+		data := d.data(ctx, now)
+		data.Flapping = true
+		data.Changed = nil
+		for _, device := range data.Devices {
+			if started[device.MACAddress] {
+				data.Changed = append(data.Changed, device)
 			}
-			d.lastChange = time.Now()
-			log.Print(ctx, log.KV{K: "msg", V: "triggered IFTTT (retrigger-after)"}, log.KV{K: "event", V: event},
+		}
+
+		event, values, err := d.trigger(ctx, now, data)
+		if d.rateLimited(ctx, err) {
+			// The warning is not worth retrying.
+		} else if err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error triggering IFTTT flapping event"})
+		} else {
+			log.Print(ctx, log.KV{K: "msg", V: "triggered IFTTT (flapping)"}, log.KV{K: "event", V: event},
 				log.KV{K: "value1", V: values.Value1},
 				log.KV{K: "value2", V: values.Value2},
 				log.KV{K: "value3", V: values.Value3})
 		}
 	}
 
-	return nil
+	return d.flap.flapping
+}
+
+// record appends the transitions of the devices and the household to the
+// history. Failing to record is logged rather than returned so that it does
+// not keep IFTTT from being triggered.
+func (d *detector) record(ctx context.Context, now time.Time) {
+	if d.history == nil {
+		return
+	}
+
+	var events []history.Event
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		if state.Changed() {
+			device := state.Device()
+			events = append(events, history.Event{
+				Time:       now,
+				MACAddress: a.MACAddress,
+				Name:       device.Name,
+				Owner:      device.Owner,
+				Present:    state.Present(),
+				Interface:  state.Interface(),
+				Zone:       d.zone,
+			})
+		}
+	}
+	if d.state.Changed() {
+		events = append(events, history.Event{Time: now, Present: d.state.Present(), Zone: d.zone})
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	if err := d.history.Record(ctx, events...); err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error recording history"})
+	}
+}
+
+// changes returns the changes of the devices and the household found by the
+// detection at now. A change of the household is made by overriding when
+// any of the changes of the devices were, or else by guest mode when it was
+// just toggled.
+func (d *detector) changes(now time.Time, guestModeChanged bool) []Change {
+	var (
+		changes    []Change
+		overridden bool
+	)
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		if state.Changed() {
+			device := state.Device()
+			source := ChangeSourceDetection
+			if _, ok := d.overridden[a.MACAddress]; ok {
+				source, overridden = ChangeSourceOverride, true
+			}
+			changes = append(changes, Change{
+				Time:       now,
+				MACAddress: a.MACAddress,
+				Name:       device.Name,
+				Owner:      device.Owner,
+				Interface:  state.Interface(),
+				Was:        !state.Present(),
+				Present:    state.Present(),
+				Source:     source,
+				Zone:       d.zone,
+			})
+		}
+	}
+	if d.state.Changed() {
+		source := ChangeSourceDetection
+		switch {
+		case overridden:
+			source = ChangeSourceOverride
+		case guestModeChanged:
+			source = ChangeSourceGuestMode
+		}
+		changes = append(changes, Change{
+			Time:    now,
+			Was:     !d.state.Present(),
+			Present: d.state.Present(),
+			Source:  source,
+			Zone:    d.zone,
+		})
+	}
+	return changes
+}
+
+// data returns the template data for triggering an IFTTT event at now.
+func (d *detector) data(ctx context.Context, now time.Time) *ifttt.Data {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error getting hostname"})
+	}
+
+	data := &ifttt.Data{
+		Timestamp: now,
+		Hostname:  hostname,
+		Present:   d.state.Present(),
+		Devices:   make([]ifttt.Device, 0, len(d.config.MACAddresses)),
+		Zone:      d.zone,
+	}
+	if !d.since.IsZero() {
+		data.Duration = now.Sub(d.since)
+	}
+
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		meta := state.Device()
+		device := ifttt.Device{
+			MACAddress: a.MACAddress,
+			Name:       meta.Name,
+			Owner:      meta.Owner,
+			Type:       meta.Type,
+			Icon:       meta.Icon,
+			Tags:       meta.Tags,
+			Present:    state.Present(),
+			Interface:  state.Interface(),
+		}
+
+		data.Devices = append(data.Devices, device)
+		if state.Changed() && !d.flaps[a.MACAddress].flapping {
+			data.Changed = append(data.Changed, device)
+		}
+	}
+
+	return data
 }
 
 func (d *detector) Config(config *Config) {
 	d.config = config
-	d.interfaces = make(neighbors.Interfaces, len(config.Interfaces))
-	for _, i := range config.Interfaces {
-		d.interfaces[i] = true
-	}
+	d.interfaces = config.NeighborsInterfaces()
+	d.overrides.setFile(config.OverridesFile)
 
 	states := make(map[string]bool, len(d.states))
 	for a := range d.states {
 		states[a] = true
 	}
 	for _, a := range config.MACAddresses {
-		if states[a] {
-			states[a] = false
+		if states[a.MACAddress] {
+			states[a.MACAddress] = false
 		} else {
-			d.states[a] = neighbors.NewState()
+			d.states[a.MACAddress] = neighbors.NewState(d.clock)
+			d.flaps[a.MACAddress] = &flapper{}
 		}
+		d.states[a.MACAddress].SetDevice(a.Neighbors())
 	}
 	for a, ok := range states {
 		if ok {
 			delete(d.states, a)
+			delete(d.flaps, a)
+			delete(d.seen, a)
 		}
 	}
 }
@@ -114,3 +574,87 @@ func (d *detector) Config(config *Config) {
 func (d *detector) Client(client ifttt.Client) {
 	d.client = client
 }
+
+func (d *detector) History(h history.History) {
+	d.history = h
+}
+
+// Runtime replaces the config, ARP, IFTTT client and history together.
+func (d *detector) Runtime(rt *Runtime) {
+	d.Config(rt.Config)
+	d.arp = rt.ARP
+	d.client = rt.Client
+	d.history = rt.History
+}
+
+func (d *detector) Subscribe(ctx context.Context) <-chan Change {
+	return d.subs.subscribe(ctx)
+}
+
+func (d *detector) SetOverride(o StateOverride) error {
+	return d.overrides.set(o)
+}
+
+func (d *detector) ClearOverride(target string) (bool, error) {
+	return d.overrides.clear(target)
+}
+
+func (d *detector) Overrides() ([]StateOverride, error) {
+	return d.overrides.list(d.clock.Now())
+}
+
+func (d *detector) SetGuestMode(enabled bool) {
+	d.guestMode.Store(enabled)
+}
+
+func (d *detector) GuestMode() bool {
+	return d.guestMode.Load()
+}
+
+// Status returns the status as of the last detection. It is safe to call
+// concurrently with Detect.
+func (d *detector) Status() *Status {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status
+}
+
+func (d *detector) updateStatus() {
+	status := &Status{
+		Present:    d.state.Present(),
+		Since:      d.since,
+		Flapping:   d.flap.flapping,
+		GuestMode:  d.guestModeApplied,
+		Suppressed: maps.Clone(d.suppressed),
+		Notifier:   d.notifier,
+		Devices:    make([]DeviceStatus, 0, len(d.config.MACAddresses)),
+		Zone:       d.zone,
+	}
+	for _, a := range d.config.MACAddresses {
+		state := d.states[a.MACAddress]
+		meta := state.Device()
+		var override *StateOverride
+		if o, ok := d.overridden[a.MACAddress]; ok {
+			override = &o
+		}
+		status.Devices = append(status.Devices, DeviceStatus{
+			MACAddress: a.MACAddress,
+			Name:       meta.Name,
+			Owner:      meta.Owner,
+			Type:       meta.Type,
+			Icon:       meta.Icon,
+			Tags:       meta.Tags,
+			Role:       string(a.role()),
+			Present:    state.Present(),
+			Interface:  state.Interface(),
+			Since:      state.Since(),
+			LastSeen:   d.seen[a.MACAddress],
+			Override:   override,
+			Flapping:   d.flaps[a.MACAddress].flapping,
+		})
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = status
+}
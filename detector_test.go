@@ -2,19 +2,29 @@ package presence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"goa.design/clue/log"
 
+	"douglasthrift.net/presence/history"
+	mockhistory "douglasthrift.net/presence/history/mocks"
 	"douglasthrift.net/presence/ifttt"
-	"douglasthrift.net/presence/neighbors"
 	mockifttt "douglasthrift.net/presence/ifttt/mocks"
+	"douglasthrift.net/presence/neighbors"
 	mockneighbors "douglasthrift.net/presence/neighbors/mocks"
+	"douglasthrift.net/presence/wrap"
 )
 
+// start is when the fake clocks of the tests start.
+var start = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+
 func TestDetect(t *testing.T) {
 	ctx := log.Context(context.Background(), log.WithDebug())
 
@@ -23,18 +33,18 @@ func TestDetect(t *testing.T) {
 	cases := []struct {
 		name   string
 		config *Config
-		setup  func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client)
+		setup  func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client)
 		err    string
 	}{
 		{
 			name: "arp error",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					return fmt.Errorf("arp failed")
 				})
@@ -45,20 +55,52 @@ func TestDetect(t *testing.T) {
 			name: "state changed triggers ifttt",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
+				PingCount:    1,
+			},
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
+				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+					for _, s := range addrStates {
+						s.Set(true)
+					}
+					state.Set(true)
+					return nil
+				})
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.True(t, data.Present)
+					return "present", &ifttt.Values{}, nil
+				})
+			},
+		},
+		{
+			name: "state changed triggers ifttt with data",
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
+				d.since = clock.Now().Add(-time.Hour)
+
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
 						s.Set(true)
+						s.SeenOn("eth0")
 					}
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
-					assert.True(t, present)
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert := assert.New(t)
+
+					device := ifttt.Device{MACAddress: mac, Present: true, Interface: "eth0"}
+					assert.True(data.Present)
+					assert.Equal(time.Hour, data.Duration)
+					assert.Equal([]ifttt.Device{device}, data.Devices)
+					assert.Equal([]ifttt.Device{device}, data.Changed)
+					assert.NotEmpty(data.Hostname)
 					return "present", &ifttt.Values{}, nil
 				})
 			},
@@ -67,11 +109,11 @@ func TestDetect(t *testing.T) {
 			name: "state changed to absent triggers ifttt",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				// First detect: become present
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -80,8 +122,8 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
-					assert.True(t, present)
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.True(t, data.Present)
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
@@ -94,8 +136,8 @@ func TestDetect(t *testing.T) {
 					state.Set(false)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
-					assert.False(t, present)
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.False(t, data.Present)
 					return "absent", &ifttt.Values{}, nil
 				})
 			},
@@ -104,11 +146,11 @@ func TestDetect(t *testing.T) {
 			name: "state changed trigger error resets state",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
 						s.Set(true)
@@ -116,7 +158,7 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "", nil, fmt.Errorf("trigger failed")
 				})
 			},
@@ -126,11 +168,11 @@ func TestDetect(t *testing.T) {
 			name: "state reset after trigger error allows retrigger",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				// First detect: trigger fails, state resets
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -139,7 +181,7 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "", nil, fmt.Errorf("trigger failed")
 				})
 				assert.ErrorContains(t, d.Detect(ctx), "trigger failed")
@@ -152,8 +194,8 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
-					assert.True(t, present)
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.True(t, data.Present)
 					return "present", &ifttt.Values{}, nil
 				})
 			},
@@ -162,11 +204,11 @@ func TestDetect(t *testing.T) {
 			name: "state not changed no trigger",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				// First detect: state changes, trigger fires
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -175,7 +217,7 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
@@ -195,12 +237,12 @@ func TestDetect(t *testing.T) {
 			config: &Config{
 				Interval:       30 * time.Second,
 				RetriggerAfter: time.Hour,
-				Interfaces:     []string{"eth0"},
-				MACAddresses:   []string{mac},
+				Interfaces:     []Interface{{Name: "eth0"}},
+				MACAddresses:   []Device{{MACAddress: mac}},
 				PingCount:      1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
-				// First detect: state changes, trigger fires, lastChange set
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
+				// First detect: state changes, trigger fires, heartbeat set
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
 						s.Set(true)
@@ -208,13 +250,12 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
 
-				// Simulate time elapsed
-				d.lastChange = time.Now().Add(-2 * time.Hour)
+				clock.Advance(2 * time.Hour)
 
 				// Setup for the test's detect: retrigger fires
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
@@ -224,8 +265,8 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
-					assert.True(t, present)
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.True(t, data.Present)
 					return "present", &ifttt.Values{}, nil
 				})
 			},
@@ -235,12 +276,12 @@ func TestDetect(t *testing.T) {
 			config: &Config{
 				Interval:       30 * time.Second,
 				RetriggerAfter: time.Hour,
-				Interfaces:     []string{"eth0"},
-				MACAddresses:   []string{mac},
+				Interfaces:     []Interface{{Name: "eth0"}},
+				MACAddresses:   []Device{{MACAddress: mac}},
 				PingCount:      1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
-				// First detect: state changes, trigger fires, lastChange set
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
+				// First detect: state changes, trigger fires, heartbeat set
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
 						s.Set(true)
@@ -248,12 +289,12 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
 
-				// lastChange was just set, so time hasn't elapsed
+				// The heartbeat was just set, so time hasn't elapsed
 				// Setup for the test's detect: no retrigger
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -269,11 +310,11 @@ func TestDetect(t *testing.T) {
 			config: &Config{
 				Interval:       30 * time.Second,
 				RetriggerAfter: time.Hour,
-				Interfaces:     []string{"eth0"},
-				MACAddresses:   []string{mac},
+				Interfaces:     []Interface{{Name: "eth0"}},
+				MACAddresses:   []Device{{MACAddress: mac}},
 				PingCount:      1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				// First detect: state changes, trigger fires
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -282,13 +323,12 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
 
-				// Simulate time elapsed
-				d.lastChange = time.Now().Add(-2 * time.Hour)
+				clock.Advance(2 * time.Hour)
 
 				// Setup for the test's detect: retrigger fails
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
@@ -298,21 +338,21 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "", nil, fmt.Errorf("retrigger failed")
 				})
 			},
 			err: "retrigger failed",
 		},
 		{
-			name: "retrigger disabled no lastChange set",
+			name: "retrigger disabled no heartbeat set",
 			config: &Config{
 				Interval:     30 * time.Second,
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac}},
 				PingCount:    1,
 			},
-			setup: func(t *testing.T, d *detector, arp *mockneighbors.ARP, client *mockifttt.Client) {
+			setup: func(t *testing.T, d *detector, clock *wrap.FakeClock, arp *mockneighbors.ARP, client *mockifttt.Client) {
 				// Detect: state changes, trigger fires, but RetriggerAfter=0
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
 					for _, s := range addrStates {
@@ -321,11 +361,11 @@ func TestDetect(t *testing.T) {
 					state.Set(true)
 					return nil
 				})
-				client.AddTrigger(func(ctx context.Context, present bool) (string, *ifttt.Values, error) {
+				client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
 					return "present", &ifttt.Values{}, nil
 				})
 				assert.NoError(t, d.Detect(ctx))
-				assert.True(t, d.lastChange.IsZero())
+				assert.True(t, d.heartbeat.last.IsZero())
 
 				// Setup for the test's detect: same state, no trigger
 				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
@@ -345,12 +385,13 @@ func TestDetect(t *testing.T) {
 
 			assert := assert.New(t)
 
+			clock := wrap.NewFakeClock(start)
 			arp := mockneighbors.NewARP(t)
 			client := mockifttt.NewClient(t)
-			d := NewDetector(tc.config, arp, client)
+			d := NewDetector(tc.config, arp, client, clock)
 
 			if tc.setup != nil {
-				tc.setup(t, d.(*detector), arp, client)
+				tc.setup(t, d.(*detector), clock, arp, client)
 			}
 
 			err := d.Detect(ctx)
@@ -374,22 +415,22 @@ func TestDetector_Config(t *testing.T) {
 	)
 
 	cases := []struct {
-		name      string
-		initial   *Config
-		updated   *Config
-		kept      []string
-		added     []string
-		removed   []string
+		name    string
+		initial *Config
+		updated *Config
+		kept    []string
+		added   []string
+		removed []string
 	}{
 		{
 			name: "keep existing mac add new",
 			initial: &Config{
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac1},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}},
 			},
 			updated: &Config{
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac1, mac2},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}, {MACAddress: mac2}},
 			},
 			kept:  []string{mac1},
 			added: []string{mac2},
@@ -397,12 +438,12 @@ func TestDetector_Config(t *testing.T) {
 		{
 			name: "remove old mac",
 			initial: &Config{
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac1, mac2},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}, {MACAddress: mac2}},
 			},
 			updated: &Config{
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac1},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}},
 			},
 			kept:    []string{mac1},
 			removed: []string{mac2},
@@ -410,16 +451,28 @@ func TestDetector_Config(t *testing.T) {
 		{
 			name: "replace all macs",
 			initial: &Config{
-				Interfaces:   []string{"eth0"},
-				MACAddresses: []string{mac1},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}},
 			},
 			updated: &Config{
-				Interfaces:   []string{"eth0", "eth1"},
-				MACAddresses: []string{mac2, mac3},
+				Interfaces:   []Interface{{Name: "eth0"}, {Name: "eth1"}},
+				MACAddresses: []Device{{MACAddress: mac2}, {MACAddress: mac3}},
 			},
 			added:   []string{mac2, mac3},
 			removed: []string{mac1},
 		},
+		{
+			name: "describe kept mac",
+			initial: &Config{
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1}},
+			},
+			updated: &Config{
+				Interfaces:   []Interface{{Name: "eth0"}},
+				MACAddresses: []Device{{MACAddress: mac1, Name: "Pixel", Owner: "Alice"}},
+			},
+			kept: []string{mac1},
+		},
 	}
 
 	for _, tc := range cases {
@@ -430,7 +483,7 @@ func TestDetector_Config(t *testing.T) {
 
 			arp := mockneighbors.NewARP(t)
 			client := mockifttt.NewClient(t)
-			d := NewDetector(tc.initial, arp, client).(*detector)
+			d := NewDetector(tc.initial, arp, client, wrap.NewFakeClock(start)).(*detector)
 
 			// Capture initial states for kept MACs
 			initialStates := make(map[string]neighbors.State)
@@ -441,9 +494,7 @@ func TestDetector_Config(t *testing.T) {
 			d.Config(tc.updated)
 
 			assert.Equal(tc.updated, d.config)
-			for _, i := range tc.updated.Interfaces {
-				assert.True(d.interfaces[i])
-			}
+			assert.Equal(tc.updated.NeighborsInterfaces(), d.interfaces)
 			for _, a := range tc.kept {
 				assert.Equal(initialStates[a], d.states[a], "kept MAC state should be preserved")
 			}
@@ -454,6 +505,9 @@ func TestDetector_Config(t *testing.T) {
 				_, exists := d.states[a]
 				assert.False(exists, "removed MAC should be deleted")
 			}
+			for _, a := range tc.updated.MACAddresses {
+				assert.Equal(a.Neighbors(), d.states[a.MACAddress].Device())
+			}
 		})
 	}
 }
@@ -464,12 +518,798 @@ func TestDetector_Client(t *testing.T) {
 	client2 := mockifttt.NewClient(t)
 
 	config := &Config{
-		Interfaces:   []string{"eth0"},
-		MACAddresses: []string{"00:00:00:00:00:01"},
+		Interfaces:   []Interface{{Name: "eth0"}},
+		MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
 	}
-	d := NewDetector(config, arp, client1).(*detector)
+	d := NewDetector(config, arp, client1, wrap.NewFakeClock(start)).(*detector)
 	assert.Equal(t, client1, d.client)
 
 	d.Client(client2)
 	assert.Equal(t, client2, d.client)
 }
+
+func TestDetector_History(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:02"
+	)
+
+	cases := []struct {
+		name   string
+		record func(t *testing.T, h *mockhistory.History)
+	}{
+		{
+			name: "records transitions",
+			record: func(t *testing.T, h *mockhistory.History) {
+				h.AddRecord(func(ctx context.Context, events ...history.Event) error {
+					assert := assert.New(t)
+
+					if assert.Len(events, 3) {
+						now := events[0].Time
+						assert.False(now.IsZero())
+						assert.Equal([]history.Event{
+							{Time: now, MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Present: true, Interface: "eth0"},
+							{Time: now, MACAddress: mac2},
+							{Time: now, Present: true},
+						}, events)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "record error does not fail detect",
+			record: func(t *testing.T, h *mockhistory.History) {
+				h.AddRecord(func(ctx context.Context, events ...history.Event) error {
+					return errors.New("disk full")
+				})
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			var (
+				arp    = mockneighbors.NewARP(t)
+				client = mockifttt.NewClient(t)
+				h      = mockhistory.NewHistory(t)
+				config = &Config{
+					Interfaces:   []Interface{{Name: "eth0"}},
+					MACAddresses: []Device{{MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice"}, {MACAddress: mac2}},
+				}
+			)
+
+			d := NewDetector(config, arp, client, wrap.NewFakeClock(start))
+			d.History(h)
+
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac1].Set(true)
+				addrStates[mac1].SeenOn("eth0")
+				addrStates[mac2].Set(false)
+				state.Set(true)
+				return nil
+			})
+			tc.record(t, h)
+			client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+				return "present", &ifttt.Values{}, nil
+			})
+			assert.NoError(d.Detect(ctx))
+
+			// Nothing is recorded without transitions.
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac1].Set(true)
+				addrStates[mac2].Set(false)
+				state.Set(true)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+
+			assert.False(arp.HasMore(), "missing expected arp calls")
+			assert.False(client.HasMore(), "missing expected client calls")
+			assert.False(h.HasMore(), "missing expected history calls")
+		})
+	}
+}
+
+func TestDetector_Flapping(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const mac = "00:00:00:00:00:01"
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		config = &Config{
+			Flapping:     Flapping{Transitions: 3, Window: time.Hour},
+			Interfaces:   []Interface{{Name: "eth0"}},
+			MACAddresses: []Device{{MACAddress: mac, Name: "Alice's Pixel"}},
+			IFTTT: IFTTT{
+				Events: Events{Flapping: Event{Event: "flapping"}},
+			},
+		}
+		clock  = wrap.NewFakeClock(start)
+		d      = NewDetector(config, arp, client, clock).(*detector)
+		detect = func(present bool) {
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac].Set(present)
+				state.Set(present)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+		}
+		trigger = func(present, flapping bool, changed []ifttt.Device) {
+			client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+				assert.Equal(present, data.Present)
+				assert.Equal(flapping, data.Flapping)
+				assert.Equal(changed, data.Changed)
+				return "event", &ifttt.Values{}, nil
+			})
+		}
+	)
+
+	trigger(true, false, []ifttt.Device{{MACAddress: mac, Name: "Alice's Pixel", Present: true}})
+	detect(true)
+	trigger(false, false, []ifttt.Device{{MACAddress: mac, Name: "Alice's Pixel"}})
+	detect(false)
+
+	// The third change starts flapping, which is warned about once instead
+	// of being notified.
+	trigger(true, true, []ifttt.Device{{MACAddress: mac, Name: "Alice's Pixel", Present: true}})
+	detect(true)
+	assert.True(d.Status().Flapping)
+	assert.True(d.Status().Devices[0].Flapping)
+	detect(false)
+	detect(true)
+	assert.False(client.HasMore(), "missing expected client calls")
+
+	// Once the window has passed without changes, the state which was not
+	// notified is.
+	clock.Advance(time.Hour)
+	trigger(true, false, nil)
+	detect(true)
+	assert.False(d.Status().Flapping)
+	detect(true)
+
+	assert.False(arp.HasMore(), "missing expected arp calls")
+	assert.False(client.HasMore(), "missing expected client calls")
+}
+
+func TestDetector_RateLimit(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+
+	const mac = "00:00:00:00:00:01"
+
+	limited := func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		return "", nil, &ifttt.RateLimitError{Event: "present", RetryAfter: time.Minute}
+	}
+
+	cases := []struct {
+		name       string
+		presents   []bool
+		triggers   []func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error)
+		suppressed map[string]uint
+	}{
+		{
+			name:     "final state delivered",
+			presents: []bool{true, true, true},
+			triggers: []func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error){
+				limited,
+				limited,
+				func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.True(t, data.Present)
+					return "present", &ifttt.Values{}, nil
+				},
+			},
+			suppressed: map[string]uint{"present": 2},
+		},
+		{
+			name:     "back to notified state",
+			presents: []bool{false, true, false, false},
+			triggers: []func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error){
+				func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+					assert.False(t, data.Present)
+					return "absent", &ifttt.Values{}, nil
+				},
+				limited,
+			},
+			suppressed: map[string]uint{"present": 1},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			var (
+				arp    = mockneighbors.NewARP(t)
+				client = mockifttt.NewClient(t)
+				config = &Config{
+					Interfaces:   []Interface{{Name: "eth0"}},
+					MACAddresses: []Device{{MACAddress: mac}},
+				}
+				d = NewDetector(config, arp, client, wrap.NewFakeClock(start))
+			)
+
+			for _, trigger := range tc.triggers {
+				client.AddTrigger(trigger)
+			}
+			for _, present := range tc.presents {
+				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+					addrStates[mac].Set(present)
+					state.Set(present)
+					return nil
+				})
+				assert.NoError(d.Detect(ctx))
+			}
+
+			assert.Equal(tc.suppressed, d.Status().Suppressed)
+			assert.False(arp.HasMore(), "missing expected arp calls")
+			assert.False(client.HasMore(), "missing expected client calls")
+		})
+	}
+}
+
+func TestDetector_Heartbeat(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+
+	const (
+		alice = "00:00:00:00:00:01"
+		bob   = "00:00:00:00:00:02"
+	)
+
+	type (
+		trigger struct {
+			present, heartbeat bool
+			owner              string
+		}
+
+		step struct {
+			after    time.Duration
+			present  map[string]bool
+			triggers []trigger
+		}
+	)
+
+	var (
+		home   = map[string]bool{alice: true}
+		away   = map[string]bool{}
+		arrive = trigger{present: true}
+		leave  = trigger{}
+		beat   = trigger{present: true, heartbeat: true}
+	)
+
+	cases := []struct {
+		name      string
+		retrigger time.Duration
+		heartbeat Heartbeat
+		event     string
+		steps     []step
+	}{
+		{
+			name:      "disabled",
+			heartbeat: Heartbeat{},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: time.Hour, present: home},
+				{after: 24 * time.Hour, present: home},
+			},
+		},
+		{
+			name:      "once",
+			heartbeat: Heartbeat{Every: time.Hour, Count: 1},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: 30 * time.Minute, present: home},
+				{after: time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 2 * time.Hour, present: home},
+				{after: 3 * time.Hour, present: home},
+			},
+		},
+		{
+			name:      "twice",
+			heartbeat: Heartbeat{Every: time.Hour, Count: 2},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 90 * time.Minute, present: home},
+				{after: 2 * time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 3 * time.Hour, present: home},
+			},
+		},
+		{
+			name:      "every period",
+			heartbeat: Heartbeat{Every: time.Hour},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 2 * time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 3 * time.Hour, present: home, triggers: []trigger{beat}},
+			},
+		},
+		{
+			name:      "retrigger after",
+			retrigger: time.Hour,
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: time.Hour, present: home, triggers: []trigger{beat}},
+				{after: 2 * time.Hour, present: home, triggers: []trigger{beat}},
+			},
+		},
+		{
+			name:      "change restarts",
+			heartbeat: Heartbeat{Every: time.Hour, Count: 1},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: 50 * time.Minute, present: away, triggers: []trigger{leave}},
+				{after: time.Hour, present: away},
+				{after: 110 * time.Minute, present: away, triggers: []trigger{{heartbeat: true}}},
+			},
+		},
+		{
+			name:      "people",
+			heartbeat: Heartbeat{Every: time.Hour, Count: 1, People: true},
+			event:     "presence_heartbeat",
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: 30 * time.Minute, present: map[string]bool{alice: true, bob: true}},
+				{after: time.Hour, present: map[string]bool{alice: true, bob: true}, triggers: []trigger{
+					beat,
+					{present: true, heartbeat: true, owner: "Alice"},
+				}},
+				{after: 90 * time.Minute, present: home},
+				{after: 2 * time.Hour, present: home},
+				{after: 150 * time.Minute, present: home, triggers: []trigger{
+					{heartbeat: true, owner: "Bob"},
+				}},
+			},
+		},
+		{
+			name:      "people without heartbeat event",
+			heartbeat: Heartbeat{Every: time.Hour, Count: 1, People: true},
+			steps: []step{
+				{present: home, triggers: []trigger{arrive}},
+				{after: 30 * time.Minute, present: map[string]bool{alice: true, bob: true}},
+				{after: time.Hour, present: map[string]bool{alice: true, bob: true}, triggers: []trigger{beat}},
+				{after: 90 * time.Minute, present: home},
+				{after: 150 * time.Minute, present: home},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			var (
+				arp    = mockneighbors.NewARP(t)
+				client = mockifttt.NewClient(t)
+				config = &Config{
+					RetriggerAfter: tc.retrigger,
+					Heartbeat:      tc.heartbeat,
+					Interfaces:     []Interface{{Name: "eth0"}},
+					MACAddresses:   []Device{{MACAddress: alice, Owner: "Alice"}, {MACAddress: bob, Owner: "Bob"}},
+					IFTTT:          IFTTT{Events: Events{Heartbeat: Event{Event: tc.event}}},
+				}
+				clock = wrap.NewFakeClock(start)
+				d     = NewDetector(config, arp, client, clock)
+			)
+
+			for i, step := range tc.steps {
+				clock.Set(start.Add(step.after))
+				arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+					for a, s := range addrStates {
+						s.Set(step.present[a])
+					}
+					state.Set(len(step.present) != 0)
+					return nil
+				})
+				for _, tr := range step.triggers {
+					client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+						assert.Equal(tr, trigger{present: data.Present, heartbeat: data.Heartbeat, owner: data.Owner}, "step %d", i)
+						assert.Equal(clock.Now(), data.Timestamp, "step %d", i)
+						return "event", &ifttt.Values{}, nil
+					})
+				}
+
+				assert.NoError(d.Detect(ctx), "step %d", i)
+				assert.False(client.HasMore(), "step %d: missing expected client calls", i)
+			}
+
+			assert.False(arp.HasMore(), "missing expected arp calls")
+		})
+	}
+}
+
+func TestDetector_Subscribe(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:02"
+	)
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		config = &Config{
+			Interfaces:   []Interface{{Name: "eth0"}},
+			MACAddresses: []Device{{MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice"}, {MACAddress: mac2}},
+		}
+		clock  = wrap.NewFakeClock(start)
+		d      = NewDetector(config, arp, client, clock)
+		detect = func(present1, present2 bool) {
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac1].Set(present1)
+				addrStates[mac1].SeenOn("eth0")
+				addrStates[mac2].Set(present2)
+				state.Set(present1 || present2)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+		}
+		receive = func(c <-chan Change) (changes []Change) {
+			for {
+				select {
+				case change := <-c:
+					changes = append(changes, change)
+				default:
+					return
+				}
+			}
+		}
+	)
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		return "present", &ifttt.Values{}, nil
+	})
+
+	subCtx, cancel := context.WithCancel(ctx)
+	c := d.Subscribe(subCtx)
+
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: start, MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Interface: "eth0", Was: false, Present: true, Source: ChangeSourceDetection},
+		{Time: start, MACAddress: mac2, Was: true, Present: false, Source: ChangeSourceDetection},
+		{Time: start, Was: false, Present: true, Source: ChangeSourceDetection},
+	}, receive(c))
+
+	clock.Advance(time.Minute)
+	detect(true, false)
+	assert.Empty(receive(c))
+
+	clock.Advance(time.Minute)
+	detect(true, true)
+	assert.Equal([]Change{
+		{Time: start.Add(2 * time.Minute), MACAddress: mac2, Was: false, Present: true, Source: ChangeSourceDetection},
+	}, receive(c))
+
+	cancel()
+	_, ok := <-c
+	assert.False(ok, "channel not closed")
+
+	assert.False(arp.HasMore(), "missing expected arp calls")
+	assert.False(client.HasMore(), "missing expected client calls")
+}
+
+func TestDetector_Override(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:02"
+	)
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		config = &Config{
+			Interfaces:    []Interface{{Name: "eth0"}},
+			MACAddresses:  []Device{{MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice"}, {MACAddress: mac2, Owner: "Bob"}},
+			OverridesFile: filepath.Join(t.TempDir(), "overrides.json"),
+		}
+		clock  = wrap.NewFakeClock(start)
+		d      = NewDetector(config, arp, client, clock)
+		detect = func(present1, present2 bool) {
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac1].Set(present1)
+				addrStates[mac2].Set(present2)
+				state.Set(present1 || present2)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+		}
+		receive = func(c <-chan Change) (changes []Change) {
+			for {
+				select {
+				case change := <-c:
+					changes = append(changes, change)
+				default:
+					return
+				}
+			}
+		}
+		triggered []bool
+	)
+	client.SetTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		triggered = append(triggered, data.Present)
+		return "present", &ifttt.Values{}, nil
+	})
+	c := d.Subscribe(ctx)
+
+	detect(true, false)
+	receive(c)
+
+	// Alice left her phone at home.
+	away := StateOverride{Target: "Alice", Until: start.Add(2 * time.Hour), Reason: "vacation", Created: start}
+	assert.NoError(d.SetOverride(away))
+	clock.Advance(time.Minute)
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: start.Add(time.Minute), MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Was: true, Present: false, Source: ChangeSourceOverride},
+		{Time: start.Add(time.Minute), Was: true, Present: false, Source: ChangeSourceOverride},
+	}, receive(c))
+	assert.Equal([]bool{true, false}, triggered)
+	status := d.Status()
+	assert.False(status.Present)
+	assert.Equal(&away, status.Devices[0].Override)
+	assert.Equal(start.Add(time.Minute), status.Devices[0].LastSeen)
+	assert.Nil(status.Devices[1].Override)
+
+	// Bob is home even though his device is not, which the household
+	// follows.
+	home := StateOverride{Target: "bob", Present: true, Created: start.Add(time.Minute)}
+	assert.NoError(d.SetOverride(home))
+	clock.Advance(time.Minute)
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: start.Add(2 * time.Minute), MACAddress: mac2, Owner: "Bob", Was: false, Present: true, Source: ChangeSourceOverride},
+		{Time: start.Add(2 * time.Minute), Was: false, Present: true, Source: ChangeSourceOverride},
+	}, receive(c))
+	assert.True(d.Status().Present)
+	assert.True(d.Status().Devices[1].LastSeen.IsZero())
+
+	// The overrides are kept across restarts.
+	overrides, err := NewDetector(config, arp, client, clock).Overrides()
+	assert.NoError(err)
+	assert.Equal([]StateOverride{away, home}, overrides)
+
+	cleared, err := d.ClearOverride("BOB")
+	assert.NoError(err)
+	assert.True(cleared)
+	cleared, err = d.ClearOverride("bob")
+	assert.NoError(err)
+	assert.False(cleared)
+	clock.Advance(time.Minute)
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: start.Add(3 * time.Minute), MACAddress: mac2, Owner: "Bob", Was: true, Present: false, Source: ChangeSourceDetection},
+		{Time: start.Add(3 * time.Minute), Was: true, Present: false, Source: ChangeSourceDetection},
+	}, receive(c))
+
+	// Alice's override expires.
+	clock.Set(away.Until)
+	overrides, err = d.Overrides()
+	assert.NoError(err)
+	assert.Empty(overrides)
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: away.Until, MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Was: false, Present: true, Source: ChangeSourceDetection},
+		{Time: away.Until, Was: false, Present: true, Source: ChangeSourceDetection},
+	}, receive(c))
+	assert.Nil(d.Status().Devices[0].Override)
+	b, err := os.ReadFile(config.OverridesFile)
+	assert.NoError(err)
+	assert.Equal("[]\n", string(b))
+
+	assert.Equal([]bool{true, false, true, false, true}, triggered)
+	assert.False(arp.HasMore(), "missing expected arp calls")
+}
+
+func TestDetector_GuestMode(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:02"
+		mac3 = "00:00:00:00:00:03"
+	)
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		config = &Config{
+			Interfaces: []Interface{{Name: "eth0"}},
+			MACAddresses: []Device{
+				{MACAddress: mac1, Owner: "Alice"},
+				{MACAddress: mac2, Owner: "Carol", Role: "guest"},
+				{MACAddress: mac3, Name: "Living Room TV", Owner: "Alice", Role: "ignored"},
+			},
+		}
+		clock  = wrap.NewFakeClock(start)
+		d      = NewDetector(config, arp, client, clock)
+		detect = func(present1, present2, present3 bool) {
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac1].Set(present1)
+				addrStates[mac2].Set(present2)
+				addrStates[mac3].Set(present3)
+				state.Set(present1 || present2 || present3)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+		}
+		household = func(c <-chan Change) (changes []Change) {
+			for {
+				select {
+				case change := <-c:
+					if change.Household() {
+						changes = append(changes, change)
+					}
+				default:
+					return
+				}
+			}
+		}
+		triggered []bool
+	)
+	client.SetTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		triggered = append(triggered, data.Present)
+		return "present", &ifttt.Values{}, nil
+	})
+	c := d.Subscribe(ctx)
+
+	// Neither Carol's phone nor the TV count while guest mode is
+	// disabled.
+	detect(true, true, true)
+	household(c)
+	clock.Advance(time.Minute)
+	detect(false, true, true)
+	assert.Equal([]Change{{Time: start.Add(time.Minute), Was: true, Present: false, Source: ChangeSourceDetection}}, household(c))
+	status := d.Status()
+	assert.False(status.Present)
+	assert.False(status.GuestMode)
+	assert.Equal([]string{"resident", "guest", "ignored"}, []string{status.Devices[0].Role, status.Devices[1].Role, status.Devices[2].Role})
+	assert.True(status.Devices[1].Present)
+	assert.True(status.Devices[2].Present)
+	assert.False(d.(*detector).people["Alice"].present)
+	assert.NotContains(d.(*detector).people, "Carol")
+
+	// Carol's phone counts once guest mode is enabled.
+	d.SetGuestMode(true)
+	assert.True(d.GuestMode())
+	assert.False(d.Status().GuestMode)
+	clock.Advance(time.Minute)
+	detect(false, true, true)
+	assert.Equal([]Change{{Time: start.Add(2 * time.Minute), Was: false, Present: true, Source: ChangeSourceGuestMode}}, household(c))
+	assert.True(d.Status().Present)
+	assert.True(d.Status().GuestMode)
+	assert.True(d.(*detector).people["Carol"].present)
+
+	clock.Advance(time.Minute)
+	detect(false, false, true)
+	assert.Equal([]Change{{Time: start.Add(3 * time.Minute), Was: true, Present: false, Source: ChangeSourceDetection}}, household(c))
+
+	// The TV never counts.
+	d.SetGuestMode(false)
+	clock.Advance(time.Minute)
+	detect(false, true, true)
+	assert.Empty(household(c))
+	assert.False(d.Status().Present)
+
+	assert.Equal([]bool{true, false, true, false}, triggered)
+	assert.False(arp.HasMore(), "missing expected arp calls")
+}
+
+func TestDetector_Runtime(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		arp1    = mockneighbors.NewARP(t)
+		arp2    = mockneighbors.NewARP(t)
+		client1 = mockifttt.NewClient(t)
+		client2 = mockifttt.NewClient(t)
+		h       = mockhistory.NewHistory(t)
+		config1 = &Config{
+			Interfaces:   []Interface{{Name: "eth0"}},
+			MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}},
+		}
+		config2 = &Config{
+			Interfaces:   []Interface{{Name: "eth1", Probe: "ping", AllowedIPs: []string{"192.168.1.0/24"}}},
+			MACAddresses: []Device{{MACAddress: "00:00:00:00:00:02"}},
+		}
+	)
+
+	d := NewDetector(config1, arp1, client1, wrap.NewFakeClock(start)).(*detector)
+	d.Runtime(&Runtime{Config: config2, ARP: arp2, Client: client2, History: h})
+
+	assert.Equal(config2, d.config)
+	assert.Equal(neighbors.Interfaces{{
+		Name:       "eth1",
+		Probe:      neighbors.ProbePing,
+		AllowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")},
+	}}, d.interfaces)
+	assert.Contains(d.states, "00:00:00:00:00:02")
+	assert.NotContains(d.states, "00:00:00:00:00:01")
+	assert.Equal(arp2, d.arp)
+	assert.Equal(client2, d.client)
+	assert.Equal(h, d.history)
+}
+
+func TestDetector_Status(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:02"
+	)
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		config = &Config{
+			Interfaces:   []Interface{{Name: "eth0"}},
+			MACAddresses: []Device{{MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Type: "phone", Tags: []string{"family"}}, {MACAddress: mac2}},
+		}
+	)
+
+	clock := wrap.NewFakeClock(start)
+	d := NewDetector(config, arp, client, clock)
+	assert.Equal(&Status{}, d.Status())
+
+	arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		addrStates[mac1].Set(true)
+		addrStates[mac1].SeenOn("eth0")
+		addrStates[mac2].Set(false)
+		state.Set(true)
+		return nil
+	})
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.Equal(ifttt.Device{
+			MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Type: "phone", Tags: []string{"family"}, Present: true, Interface: "eth0",
+		}, data.Devices[0])
+		return "present", &ifttt.Values{}, nil
+	})
+	assert.NoError(d.Detect(ctx))
+
+	status := d.Status()
+	assert.True(status.Present)
+	assert.Equal(start, status.Since)
+	assert.Equal(NotifierStatus{LastTriggered: start, LastEvent: "present"}, status.Notifier)
+	assert.True(status.Notifier.Healthy())
+	assert.Equal([]DeviceStatus{
+		{MACAddress: mac1, Name: "Alice's Pixel", Owner: "Alice", Type: "phone", Tags: []string{"family"}, Role: "resident", Present: true, Interface: "eth0", Since: start, LastSeen: start},
+		{MACAddress: mac2, Role: "resident", Since: start},
+	}, status.Devices)
+
+	later := start.Add(time.Minute)
+	clock.Set(later)
+	arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		addrStates[mac1].Set(false)
+		addrStates[mac2].Set(false)
+		state.Set(false)
+		return nil
+	})
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		return "", nil, errors.New("IFTTT unavailable")
+	})
+	assert.EqualError(d.Detect(ctx), "IFTTT unavailable")
+
+	status = d.Status()
+	assert.Equal(NotifierStatus{LastTriggered: start, LastEvent: "present", LastError: "IFTTT unavailable", LastFailed: later, Failures: 1}, status.Notifier)
+	assert.False(status.Notifier.Healthy())
+	assert.Equal(later, status.Devices[0].Since)
+	assert.Equal(start, status.Devices[0].LastSeen)
+}
@@ -0,0 +1,99 @@
+package presence
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"douglasthrift.net/presence/neighbors"
+)
+
+var (
+	deviceType = reflect.TypeOf(Device{})
+)
+
+type (
+	// plainDevice has the fields of Device without its unmarshalers.
+	plainDevice Device
+
+	// Role is how a device counts toward the household being present.
+	Role string
+)
+
+const (
+	// RoleResident devices always count.
+	RoleResident Role = "resident"
+	// RoleGuest devices only count while guest mode is enabled.
+	RoleGuest Role = "guest"
+	// RoleIgnored devices never count (e.g. ones which are always on).
+	RoleIgnored Role = "ignored"
+)
+
+// Roles are all of the valid roles.
+var Roles = []Role{RoleResident, RoleGuest, RoleIgnored}
+
+// ParseRole returns the role named s, or [RoleResident] when s is empty.
+func ParseRole(s string) (Role, error) {
+	if s == "" {
+		return RoleResident, nil
+	}
+	for _, r := range Roles {
+		if Role(s) == r {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("invalid role %#v", s)
+}
+
+// Counts returns whether a present device with the role makes the
+// household present.
+func (r Role) Counts(guestMode bool) bool {
+	return r == RoleResident || r == RoleGuest && guestMode
+}
+
+// UnmarshalYAML decodes the device from its MAC address or an object. JSON
+// config files are decoded the same way.
+func (d *Device) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return n.Decode(&d.MACAddress)
+	}
+	return decodeYAMLObject(n, (*plainDevice)(d), deviceType)
+}
+
+// UnmarshalTOML decodes the device from its MAC address or a table.
+func (d *Device) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		d.MACAddress = v
+		return nil
+	case map[string]any:
+		return decodeTOMLTable(v, (*plainDevice)(d), "device")
+	default:
+		return fmt.Errorf("expected a MAC address or table, got %T", v)
+	}
+}
+
+// UnmarshalText decodes the device from its MAC address so that devices can
+// be set by environment variables and overrides.
+func (d *Device) UnmarshalText(b []byte) error {
+	*d = Device{MACAddress: string(b)}
+	return nil
+}
+
+// Neighbors returns the description of the device tracked with its state.
+func (d Device) Neighbors() neighbors.Device {
+	return neighbors.Device{
+		Name:  d.Name,
+		Owner: d.Owner,
+		Type:  d.Type,
+		Icon:  d.Icon,
+		Tags:  d.Tags,
+	}
+}
+
+// role returns the role of the validated device.
+func (d Device) role() Role {
+	r, _ := ParseRole(d.Role)
+	return r
+}
@@ -0,0 +1,44 @@
+package presence
+
+import (
+	"time"
+)
+
+type (
+	// flapper tracks the recent changes of the household or a device to
+	// tell whether it is flapping.
+	flapper struct {
+		changes  []time.Time
+		flapping bool
+	}
+)
+
+// update records whether there was a change at now and returns whether
+// flapping started or stopped.
+func (f *flapper) update(changed bool, now time.Time, config Flapping) (started, stopped bool) {
+	if config.Transitions == 0 {
+		stopped = f.flapping
+		*f = flapper{}
+		return false, stopped
+	}
+
+	if changed {
+		f.changes = append(f.changes, now)
+	}
+	cutoff := now.Add(-config.Window)
+	i := 0
+	for i < len(f.changes) && !f.changes[i].After(cutoff) {
+		i++
+	}
+	f.changes = f.changes[i:]
+
+	switch {
+	case !f.flapping && uint(len(f.changes)) >= config.Transitions:
+		f.flapping = true
+		return true, false
+	case f.flapping && len(f.changes) == 0:
+		f.flapping = false
+		return false, true
+	}
+	return false, false
+}
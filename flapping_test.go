@@ -0,0 +1,99 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlapper_Update(t *testing.T) {
+	var (
+		start  = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+		config = Flapping{Transitions: 3, Window: 10 * time.Minute}
+	)
+
+	type update struct {
+		changed          bool
+		after            time.Duration
+		started, stopped bool
+	}
+
+	cases := []struct {
+		name    string
+		config  Flapping
+		updates []update
+	}{
+		{
+			name:   "disabled",
+			config: Flapping{},
+			updates: []update{
+				{changed: true},
+				{changed: true, after: time.Minute},
+				{changed: true, after: 2 * time.Minute},
+				{changed: true, after: 3 * time.Minute},
+			},
+		},
+		{
+			name:   "changes spread out",
+			config: config,
+			updates: []update{
+				{changed: true},
+				{changed: true, after: 6 * time.Minute},
+				{changed: true, after: 12 * time.Minute},
+				{changed: true, after: 18 * time.Minute},
+			},
+		},
+		{
+			name:   "starts and stops",
+			config: config,
+			updates: []update{
+				{changed: true},
+				{changed: true, after: time.Minute},
+				{changed: true, after: 2 * time.Minute, started: true},
+				{changed: true, after: 3 * time.Minute},
+				{after: 12 * time.Minute},
+				{after: 13 * time.Minute, stopped: true},
+				{after: 14 * time.Minute},
+			},
+		},
+		{
+			name:   "changes keep it flapping",
+			config: config,
+			updates: []update{
+				{changed: true},
+				{changed: true, after: time.Minute},
+				{changed: true, after: 2 * time.Minute, started: true},
+				{changed: true, after: 11 * time.Minute},
+				{after: 20 * time.Minute},
+				{after: 21 * time.Minute, stopped: true},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := &flapper{}
+			for i, u := range tc.updates {
+				started, stopped := f.update(u.changed, start.Add(u.after), tc.config)
+				assert.Equal(t, u.started, started, "update %d started", i)
+				assert.Equal(t, u.stopped, stopped, "update %d stopped", i)
+			}
+		})
+	}
+
+	t.Run("disabling stops", func(t *testing.T) {
+		f := &flapper{}
+		for i := range 3 {
+			f.update(true, start.Add(time.Duration(i)*time.Minute), config)
+		}
+		assert.True(t, f.flapping)
+
+		started, stopped := f.update(false, start.Add(3*time.Minute), Flapping{})
+		assert.False(t, started)
+		assert.True(t, stopped)
+		assert.Equal(t, flapper{}, *f)
+	})
+}
@@ -0,0 +1,140 @@
+package presence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatYAML = "yaml"
+	formatJSON = "json"
+	formatTOML = "toml"
+)
+
+// configFormat returns the format of the config file name from its
+// extension. Files without a recognized extension are YAML.
+func configFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return formatJSON
+	case ".toml":
+		return formatTOML
+	default:
+		return formatYAML
+	}
+}
+
+// decodeConfig strictly decodes the config file name with contents b into c,
+// returning the fields set in it as nested maps.
+func decodeConfig(name string, b []byte, c *Config) (map[string]any, error) {
+	m := map[string]any{}
+	switch configFormat(name) {
+	case formatTOML:
+		md, err := toml.Decode(string(b), c)
+		if err != nil {
+			return nil, err
+		}
+
+		if u := md.Undecoded(); len(u) != 0 {
+			fields := make([]string, 0, len(u))
+			for _, k := range u {
+				fields = append(fields, k.String())
+			}
+			return nil, fmt.Errorf("toml: unknown fields: %v", strings.Join(fields, ", "))
+		}
+
+		_, _ = toml.Decode(string(b), &m)
+		return m, nil
+	case formatJSON:
+		// JSON is a subset of YAML, so once it is known to be valid JSON
+		// it is decoded the same way as YAML.
+		if !json.Valid(b) {
+			var v any
+			err := json.Unmarshal(b, &v)
+			if err == nil {
+				err = errors.New("invalid JSON")
+			}
+			return nil, fmt.Errorf("json: %w", err)
+		}
+	}
+
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+
+	err := d.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	_ = yaml.Unmarshal(b, &m)
+	return m, nil
+}
+
+// decodeYAMLObject strictly decodes the mapping node n into v, a pointer to
+// a struct of type t without its unmarshalers. Decoding a node does not know
+// about unknown fields, so they are checked here to be as strict as the rest
+// of the config file.
+func decodeYAMLObject(n *yaml.Node, v any, t reflect.Type) error {
+	if n.Kind == yaml.MappingNode {
+		var unknown []string
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if k := n.Content[i]; !hasField(t, k.Value) {
+				unknown = append(unknown, fmt.Sprintf("line %d: field %v not found in type %v", k.Line, k.Value, t))
+			}
+		}
+		if len(unknown) != 0 {
+			return &yaml.TypeError{Errors: unknown}
+		}
+	}
+
+	return n.Decode(v)
+}
+
+// decodeTOMLTable strictly decodes the table m into v, a pointer to a struct
+// without its unmarshalers. What names the struct in errors.
+func decodeTOMLTable(m map[string]any, v any, what string) error {
+	b, err := toml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	md, err := toml.Decode(string(b), v)
+	if err != nil {
+		return err
+	}
+
+	if u := md.Undecoded(); len(u) != 0 {
+		fields := make([]string, 0, len(u))
+		for _, k := range u {
+			fields = append(fields, k.String())
+		}
+		return fmt.Errorf("unknown %v fields: %v", what, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+func hasField(t reflect.Type, name string) bool {
+	_, ok := fieldByTag(t, name)
+	return ok
+}
+
+// fieldByTag returns the field of the struct type t named name in the config
+// file.
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if n, _, _ := strings.Cut(f.Tag.Get("yaml"), ","); n == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,45 @@
+package presence
+
+import (
+	"time"
+)
+
+type (
+	// heartbeat tracks the heartbeats triggered while the state of the
+	// household or a person holds.
+	heartbeat struct {
+		// last is when the state last changed or the last heartbeat was
+		// triggered. It is zero until then.
+		last  time.Time
+		count uint
+	}
+
+	// person is the presence of the devices of an owner, who is present
+	// while any of them are.
+	person struct {
+		present   bool
+		heartbeat heartbeat
+	}
+)
+
+// reset restarts the heartbeats after the state changed at now.
+func (h *heartbeat) reset(now time.Time) {
+	*h = heartbeat{last: now}
+}
+
+// due returns whether a heartbeat is due at now.
+func (h *heartbeat) due(now time.Time, policy Heartbeat) bool {
+	switch {
+	case policy.Every <= 0 || h.last.IsZero():
+		return false
+	case policy.Count != 0 && h.count >= policy.Count:
+		return false
+	}
+	return now.Sub(h.last) >= policy.Every
+}
+
+// beat records that a heartbeat was triggered at now.
+func (h *heartbeat) beat(now time.Time) {
+	h.last = now
+	h.count++
+}
@@ -0,0 +1,67 @@
+package presence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeat(t *testing.T) {
+	start := time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		policy Heartbeat
+		beats  []time.Duration
+		due    []bool
+	}{
+		{
+			name:  "disabled",
+			beats: []time.Duration{time.Hour, 2 * time.Hour},
+			due:   []bool{false, false},
+		},
+		{
+			name:   "once",
+			policy: Heartbeat{Every: time.Hour, Count: 1},
+			beats:  []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour},
+			due:    []bool{false, true, false},
+		},
+		{
+			name:   "count",
+			policy: Heartbeat{Every: time.Hour, Count: 2},
+			beats:  []time.Duration{time.Hour, 90 * time.Minute, 2 * time.Hour, 3 * time.Hour},
+			due:    []bool{true, false, true, false},
+		},
+		{
+			name:   "every period",
+			policy: Heartbeat{Every: time.Hour},
+			beats:  []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 4 * time.Hour},
+			due:    []bool{true, true, true, true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			var h heartbeat
+			assert.False(h.due(start.Add(24*time.Hour), tc.policy), "before reset")
+
+			h.reset(start)
+			for i, after := range tc.beats {
+				now := start.Add(after)
+				due := h.due(now, tc.policy)
+				assert.Equal(tc.due[i], due, "beat %d", i)
+				if due {
+					h.beat(now)
+				}
+			}
+
+			h.reset(start.Add(24 * time.Hour))
+			assert.Equal(heartbeat{last: start.Add(24 * time.Hour)}, h)
+		})
+	}
+}
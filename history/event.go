@@ -0,0 +1,80 @@
+package history
+
+import (
+	"strings"
+	"time"
+)
+
+type (
+	// Event is a recorded transition of a device or of the household.
+	Event struct {
+		Time time.Time `json:"time"`
+		// MACAddress is the device which changed, or empty when the
+		// household did.
+		MACAddress string `json:"mac_address,omitempty"`
+		Name       string `json:"name,omitempty"`
+		Owner      string `json:"owner,omitempty"`
+		Present    bool   `json:"present"`
+		// Interface is the network interface the device was seen on.
+		Interface string `json:"interface,omitempty"`
+		// Zone is the zone of the household or device, or empty for the
+		// household of the config.
+		Zone string `json:"zone,omitempty"`
+	}
+
+	// Filter selects events. Zero fields select everything.
+	Filter struct {
+		// Device is the MAC address or name of a device.
+		Device string
+		// Owner is the person whose devices are selected.
+		Owner string
+		// Household selects only household events.
+		Household bool
+		// Zone is the zone whose household and devices are selected.
+		Zone string
+		// Since and Until select events at or after Since and before Until.
+		Since, Until time.Time
+	}
+)
+
+// Household returns whether the event is a transition of the household.
+func (e Event) Household() bool {
+	return e.MACAddress == ""
+}
+
+// Label returns the name of the device, its MAC address when it has no
+// name, or "household".
+func (e Event) Label() string {
+	switch {
+	case e.Household():
+		return "household"
+	case e.Name != "":
+		return e.Name
+	default:
+		return e.MACAddress
+	}
+}
+
+// Match returns whether the filter selects the event. Devices, owners and
+// zones are compared case insensitively.
+func (f Filter) Match(e Event) bool {
+	if f.Household && !e.Household() {
+		return false
+	}
+	if f.Device != "" && !strings.EqualFold(f.Device, e.MACAddress) && !strings.EqualFold(f.Device, e.Name) {
+		return false
+	}
+	if f.Owner != "" && !strings.EqualFold(f.Owner, e.Owner) {
+		return false
+	}
+	if f.Zone != "" && !strings.EqualFold(f.Zone, e.Zone) {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
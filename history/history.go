@@ -0,0 +1,243 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// History is an on-disk log of presence transitions.
+	History interface {
+		Record(ctx context.Context, events ...Event) error
+		Query(filter Filter) ([]Event, error)
+	}
+
+	// Retention limits how much history is kept. Zero fields are
+	// unlimited.
+	Retention struct {
+		MaxAge    time.Duration
+		MaxEvents uint
+	}
+
+	history struct {
+		path      string
+		retention Retention
+		clock     wrap.Clock
+
+		mu sync.Mutex
+		// count is the number of events in the file, or -1 when unknown.
+		count     int
+		lastPrune time.Time
+	}
+)
+
+const (
+	// pruneInterval is how often events older than the maximum age are
+	// removed.
+	pruneInterval = time.Hour
+	// pruneSlack is the fraction of the maximum events by which the history
+	// may exceed them before it is pruned, so that it is not rewritten on
+	// every record.
+	pruneSlack = 0.1
+)
+
+// New returns the history stored as JSON Lines in the file path, which is
+// pruned by the clock. The file is only opened while recording or querying
+// so that it can be read by another process while the daemon is running.
+func New(path string, retention Retention, clock wrap.Clock) History {
+	return &history{
+		path:      path,
+		retention: retention,
+		clock:     clock,
+		count:     -1,
+	}
+}
+
+// Record appends the events to the history and then removes the events
+// beyond the retention limits once there are enough of them.
+func (h *history) Record(ctx context.Context, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.append(events); err != nil {
+		return err
+	}
+	if h.count >= 0 {
+		h.count += len(events)
+	}
+
+	now := h.clock.Now()
+	if h.count < 0 || h.retention.MaxEvents != 0 && h.count > h.retention.pruneAt() ||
+		h.retention.MaxAge != 0 && now.Sub(h.lastPrune) >= pruneInterval {
+		removed, err := h.prune(now)
+		if err != nil {
+			return fmt.Errorf("pruning history: %w", err)
+		}
+		if removed != 0 {
+			log.Debug(ctx, log.KV{K: "msg", V: "pruned history"}, log.KV{K: "file", V: h.path}, log.KV{K: "removed", V: removed})
+		}
+	}
+
+	return nil
+}
+
+// Query returns the events selected by the filter in the order they were
+// recorded, leaving out those beyond the maximum events which have not been
+// pruned yet. A history which has not been recorded to yet is empty.
+func (h *history) Query(filter Filter) ([]Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	if max := int(h.retention.MaxEvents); max != 0 && len(events) > max {
+		events = events[len(events)-max:]
+	}
+
+	selected := make([]Event, 0, len(events))
+	for _, e := range events {
+		if filter.Match(e) {
+			selected = append(selected, e)
+		}
+	}
+	return selected, nil
+}
+
+func (h *history) append(events []Event) (err error) {
+	if err = os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	if err = encode(w, events); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (h *history) read() ([]Event, error) {
+	f, err := os.Open(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var (
+		events []Event
+		s      = bufio.NewScanner(f)
+	)
+	for line := 1; s.Scan(); line++ {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+
+		var e Event
+		if err = json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%v:%d: %w", h.path, line, err)
+		}
+		events = append(events, e)
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// pruneAt returns how many events there may be before they are pruned.
+func (r Retention) pruneAt() int {
+	return int(r.MaxEvents) + max(int(float64(r.MaxEvents)*pruneSlack), 1)
+}
+
+// prune rewrites the history without the events beyond the retention
+// limits, returning how many were removed.
+func (h *history) prune(now time.Time) (int, error) {
+	events, err := h.read()
+	if err != nil {
+		return 0, err
+	}
+	h.lastPrune = now
+
+	keep := events
+	if h.retention.MaxAge != 0 {
+		cutoff := now.Add(-h.retention.MaxAge)
+		for len(keep) != 0 && keep[0].Time.Before(cutoff) {
+			keep = keep[1:]
+		}
+	}
+	if max := int(h.retention.MaxEvents); max != 0 && len(keep) > max {
+		keep = keep[len(keep)-max:]
+	}
+	h.count = len(keep)
+
+	removed := len(events) - len(keep)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	w := bufio.NewWriter(tmp)
+	if err = encode(w, keep); err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err = w.Flush(); err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+
+	return removed, os.Rename(tmp.Name(), h.path)
+}
+
+func encode(w io.Writer, events []Event) error {
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	for _, event := range events {
+		if err := e.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
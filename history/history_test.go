@@ -0,0 +1,244 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/wrap"
+)
+
+var (
+	start = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+
+	events = []Event{
+		{Time: start, MACAddress: "00:00:00:00:00:01", Name: "Alice's Pixel", Owner: "Alice", Present: true, Interface: "eth0"},
+		{Time: start, Present: true},
+		{Time: start.Add(time.Hour), MACAddress: "00:00:00:00:00:02", Owner: "Bob", Present: true, Interface: "wlan0"},
+		{Time: start.Add(2 * time.Hour), MACAddress: "00:00:00:00:00:01", Name: "Alice's Pixel", Owner: "Alice"},
+		{Time: start.Add(3 * time.Hour), MACAddress: "00:00:00:00:00:02", Owner: "Bob"},
+		{Time: start.Add(3 * time.Hour)},
+	}
+)
+
+func TestHistory_Record(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "history", "presence.jsonl")
+	h := New(path, Retention{}, wrap.NewFakeClock(start))
+
+	assert.NoError(h.Record(ctx))
+	assert.NoFileExists(path)
+
+	assert.NoError(h.Record(ctx, events[:2]...))
+	assert.NoError(h.Record(ctx, events[2:]...))
+
+	b, err := os.ReadFile(path)
+	if assert.NoError(err) {
+		assert.Equal(`{"time":"2022-03-04T08:00:00Z","mac_address":"00:00:00:00:00:01","name":"Alice's Pixel","owner":"Alice","present":true,"interface":"eth0"}`+"\n"+
+			`{"time":"2022-03-04T08:00:00Z","present":true}`+"\n"+
+			`{"time":"2022-03-04T09:00:00Z","mac_address":"00:00:00:00:00:02","owner":"Bob","present":true,"interface":"wlan0"}`+"\n"+
+			`{"time":"2022-03-04T10:00:00Z","mac_address":"00:00:00:00:00:01","name":"Alice's Pixel","owner":"Alice","present":false}`+"\n"+
+			`{"time":"2022-03-04T11:00:00Z","mac_address":"00:00:00:00:00:02","owner":"Bob","present":false}`+"\n"+
+			`{"time":"2022-03-04T11:00:00Z","present":false}`+"\n", string(b))
+	}
+
+	all, err := h.Query(Filter{})
+	if assert.NoError(err) {
+		assert.Equal(events, all)
+	}
+}
+
+func TestHistory_Retention(t *testing.T) {
+	cases := []struct {
+		name      string
+		retention Retention
+		now       time.Time
+		kept      []Event
+	}{
+		{
+			name: "unlimited",
+			now:  start.Add(24 * time.Hour),
+			kept: events,
+		},
+		{
+			name:      "max age",
+			retention: Retention{MaxAge: 2 * time.Hour},
+			now:       start.Add(4 * time.Hour),
+			kept:      events[3:],
+		},
+		{
+			name:      "max events",
+			retention: Retention{MaxEvents: 2},
+			now:       start.Add(4 * time.Hour),
+			kept:      events[4:],
+		},
+		{
+			name:      "both",
+			retention: Retention{MaxAge: 4 * time.Hour, MaxEvents: 3},
+			now:       start.Add(5 * time.Hour),
+			kept:      events[3:],
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			assert := assert.New(t)
+
+			path := filepath.Join(t.TempDir(), "presence.jsonl")
+			h := New(path, tc.retention, wrap.NewFakeClock(tc.now))
+
+			assert.NoError(h.Record(ctx, events...))
+
+			kept, err := h.Query(Filter{})
+			if assert.NoError(err) {
+				assert.Equal(tc.kept, kept)
+			}
+		})
+	}
+}
+
+func TestHistory_Retention_Slack(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		path   = filepath.Join(t.TempDir(), "presence.jsonl")
+		h      = New(path, Retention{MaxEvents: 10}, wrap.NewFakeClock(start))
+		events = make([]Event, 12)
+		lines  = func() int {
+			b, err := os.ReadFile(path)
+			assert.NoError(err)
+			return bytes.Count(b, []byte("\n"))
+		}
+	)
+	for i := range events {
+		events[i] = Event{Time: start.Add(time.Duration(i) * time.Minute), Present: i%2 == 0}
+	}
+
+	assert.NoError(h.Record(ctx, events[:10]...))
+	assert.Equal(10, lines())
+
+	// The history is not pruned until it is over the maximum by enough,
+	// but queries leave out the events beyond it.
+	assert.NoError(h.Record(ctx, events[10]))
+	assert.Equal(11, lines())
+	kept, err := h.Query(Filter{})
+	if assert.NoError(err) {
+		assert.Equal(events[1:11], kept)
+	}
+
+	assert.NoError(h.Record(ctx, events[11]))
+	assert.Equal(10, lines())
+	kept, err = h.Query(Filter{})
+	if assert.NoError(err) {
+		assert.Equal(events[2:], kept)
+	}
+}
+
+func TestHistory_Query(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "presence.jsonl")
+	h := New(path, Retention{}, wrap.NewFakeClock(start))
+	assert.NoError(t, h.Record(ctx, events...))
+	zoned := []Event{
+		{Time: start.Add(4 * time.Hour), MACAddress: "00:00:00:00:00:03", Owner: "Dave", Present: true, Zone: "Cottage"},
+		{Time: start.Add(4 * time.Hour), Present: true, Zone: "Cottage"},
+	}
+	assert.NoError(t, h.Record(ctx, zoned...))
+
+	cases := []struct {
+		name     string
+		filter   Filter
+		selected []Event
+	}{
+		{
+			name:     "all",
+			selected: append(append([]Event{}, events...), zoned...),
+		},
+		{
+			name:     "device by MAC address",
+			filter:   Filter{Device: "00:00:00:00:00:02"},
+			selected: []Event{events[2], events[4]},
+		},
+		{
+			name:     "device by name",
+			filter:   Filter{Device: "alice's pixel"},
+			selected: []Event{events[0], events[3]},
+		},
+		{
+			name:     "owner",
+			filter:   Filter{Owner: "bob"},
+			selected: []Event{events[2], events[4]},
+		},
+		{
+			name:     "household",
+			filter:   Filter{Household: true},
+			selected: []Event{events[1], events[5], zoned[1]},
+		},
+		{
+			name:     "time range",
+			filter:   Filter{Since: start.Add(time.Hour), Until: start.Add(3 * time.Hour)},
+			selected: []Event{events[2], events[3]},
+		},
+		{
+			name:     "zone",
+			filter:   Filter{Zone: "cottage"},
+			selected: zoned,
+		},
+		{
+			name:     "zone household",
+			filter:   Filter{Zone: "Cottage", Household: true},
+			selected: []Event{zoned[1]},
+		},
+		{
+			name:     "none",
+			filter:   Filter{Owner: "Carol"},
+			selected: []Event{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+
+			selected, err := h.Query(tc.filter)
+			if assert.NoError(err) {
+				assert.Equal(tc.selected, selected)
+			}
+		})
+	}
+}
+
+func TestHistory_Query_Errors(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	events, err := New(filepath.Join(dir, "nonexistent.jsonl"), Retention{}, wrap.NewFakeClock(start)).Query(Filter{})
+	assert.NoError(err)
+	assert.Empty(events)
+
+	path := filepath.Join(dir, "invalid.jsonl")
+	assert.NoError(os.WriteFile(path, []byte(`{"time":"2022-03-04T08:00:00Z"}`+"\n\nnot JSON\n"), 0o644))
+	_, err = New(path, Retention{}, wrap.NewFakeClock(start)).Query(Filter{})
+	assert.EqualError(err, path+":3: invalid character 'o' in literal null (expecting 'u')")
+}
+
+func TestEvent_Label(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("Alice's Pixel", events[0].Label())
+	assert.Equal("household", events[1].Label())
+	assert.Equal("00:00:00:00:00:02", events[2].Label())
+}
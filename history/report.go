@@ -0,0 +1,247 @@
+package history
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Report is a summary of the recorded transitions of the household and
+	// each person over a period.
+	Report struct {
+		// Zone is the zone of the household, or empty for the household of
+		// the config.
+		Zone      string    `json:"zone,omitempty"`
+		Since     time.Time `json:"since"`
+		Until     time.Time `json:"until"`
+		Household Stats     `json:"household"`
+		// People are the owners of devices in order of name. Devices with
+		// no owner only count towards the household.
+		People []Stats `json:"people"`
+	}
+
+	// Stats summarizes the transitions of the household or a person. A
+	// person is present while any of their devices are.
+	Stats struct {
+		Name string `json:"name"`
+		// Occupancy is the percentage of the observed time spent present.
+		Occupancy float64  `json:"occupancy"`
+		Present   Duration `json:"present"`
+		// Observed is how much of the period the state was known.
+		Observed         Duration  `json:"observed"`
+		Arrivals         int       `json:"arrivals"`
+		Departures       int       `json:"departures"`
+		TypicalArrival   string    `json:"typical_arrival,omitempty"`
+		TypicalDeparture string    `json:"typical_departure,omitempty"`
+		LongestAbsences  []Absence `json:"longest_absences"`
+		// Flaps is how many times the state changed back within the flap
+		// window.
+		Flaps int `json:"flaps"`
+	}
+
+	// Absence is a period spent absent, clipped to the report's period.
+	Absence struct {
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Duration Duration  `json:"duration"`
+		// Ongoing is whether the absence had not ended by the end of the
+		// report's period.
+		Ongoing bool `json:"ongoing,omitempty"`
+	}
+
+	// Duration is a time.Duration which is encoded as JSON like "1h30m0s".
+	Duration time.Duration
+
+	transition struct {
+		time    time.Time
+		present bool
+	}
+)
+
+const (
+	// longestAbsences is how many absences are reported.
+	longestAbsences = 3
+	// clock is the layout of typical arrival and departure times.
+	clock = "15:04"
+)
+
+// NewReport summarizes the events of the zone, or of the household of the
+// config when it is empty, between since and until. Events before since are
+// used to find the state at the start of the period and the events must be
+// in the order they were recorded. Typical times are in the location of
+// since.
+func NewReport(events []Event, zone string, since, until time.Time, flapWindow time.Duration) *Report {
+	var (
+		household []transition
+		people    = make(map[string][]transition)
+		devices   = make(map[string]map[string]bool)
+	)
+
+	for _, e := range events {
+		if !e.Time.Before(until) {
+			break
+		}
+		if !strings.EqualFold(e.Zone, zone) {
+			continue
+		}
+		t := e.Time.In(since.Location())
+
+		if e.Household() {
+			household = appendTransition(household, t, e.Present)
+			continue
+		}
+		if e.Owner == "" {
+			continue
+		}
+
+		owned := devices[e.Owner]
+		if owned == nil {
+			owned = make(map[string]bool)
+			devices[e.Owner] = owned
+		}
+		owned[e.MACAddress] = e.Present
+
+		present := false
+		for _, p := range owned {
+			present = present || p
+		}
+		people[e.Owner] = appendTransition(people[e.Owner], t, present)
+	}
+
+	r := &Report{
+		Zone:      zone,
+		Since:     since,
+		Until:     until,
+		Household: summarize("household", household, since, until, flapWindow),
+		People:    make([]Stats, 0, len(people)),
+	}
+	for name, ts := range people {
+		r.People = append(r.People, summarize(name, ts, since, until, flapWindow))
+	}
+	sort.Slice(r.People, func(i, j int) bool { return r.People[i].Name < r.People[j].Name })
+	return r
+}
+
+// String returns the duration like time.Duration does.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes the duration from a string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// appendTransition appends a transition when the state changes.
+func appendTransition(ts []transition, t time.Time, present bool) []transition {
+	if len(ts) != 0 && ts[len(ts)-1].present == present {
+		return ts
+	}
+	return append(ts, transition{time: t, present: present})
+}
+
+func summarize(name string, ts []transition, since, until time.Time, flapWindow time.Duration) Stats {
+	var (
+		s = Stats{
+			Name:            name,
+			LongestAbsences: []Absence{},
+		}
+		present, observed    time.Duration
+		arrivals, departures []time.Time
+	)
+
+	for i, t := range ts {
+		var (
+			next  = until
+			ended = i+1 < len(ts)
+		)
+		if ended {
+			next = ts[i+1].time
+		}
+
+		// The first transition is when the state became known rather than
+		// an arrival or departure.
+		if i != 0 && !t.time.Before(since) {
+			if t.present {
+				arrivals = append(arrivals, t.time)
+			} else {
+				departures = append(departures, t.time)
+			}
+			if ended && next.Sub(t.time) < flapWindow {
+				s.Flaps++
+			}
+		}
+
+		start, end := t.time, next
+		if start.Before(since) {
+			start = since
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		d := end.Sub(start)
+		observed += d
+		if t.present {
+			present += d
+		} else {
+			s.LongestAbsences = append(s.LongestAbsences, Absence{
+				Start:    start,
+				End:      end,
+				Duration: Duration(d),
+				Ongoing:  !ended,
+			})
+		}
+	}
+
+	s.Present, s.Observed = Duration(present), Duration(observed)
+	if observed != 0 {
+		s.Occupancy = 100 * float64(present) / float64(observed)
+	}
+	s.Arrivals, s.Departures = len(arrivals), len(departures)
+	s.TypicalArrival, s.TypicalDeparture = typical(arrivals), typical(departures)
+
+	sort.SliceStable(s.LongestAbsences, func(i, j int) bool {
+		return s.LongestAbsences[i].Duration > s.LongestAbsences[j].Duration
+	})
+	if len(s.LongestAbsences) > longestAbsences {
+		s.LongestAbsences = s.LongestAbsences[:longestAbsences]
+	}
+	return s
+}
+
+// typical returns the median time of day of the times.
+func typical(times []time.Time) string {
+	if len(times) == 0 {
+		return ""
+	}
+
+	clocks := make([]time.Duration, 0, len(times))
+	for _, t := range times {
+		clocks = append(clocks, t.Sub(midnight(t)))
+	}
+	slices.Sort(clocks)
+
+	median := clocks[len(clocks)/2]
+	if len(clocks)%2 == 0 {
+		median = (clocks[len(clocks)/2-1] + median) / 2
+	}
+	return time.Time{}.Add(median).Format(clock)
+}
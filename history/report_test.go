@@ -0,0 +1,208 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReport(t *testing.T) {
+	const (
+		alice  = "00:00:00:00:00:01"
+		bob    = "00:00:00:00:00:02"
+		other  = "00:00:00:00:00:03"
+		alice2 = "00:00:00:00:00:04"
+	)
+
+	var (
+		since = time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC)
+		until = since.Add(48 * time.Hour)
+		at    = func(day, hour, minute int) time.Time {
+			return since.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		}
+		both = func(tm time.Time, present bool) []Event {
+			return []Event{
+				{Time: tm, MACAddress: alice, Owner: "Alice", Present: present},
+				{Time: tm, Present: present},
+			}
+		}
+		flapping = func() (events []Event) {
+			events = append(events, both(at(-1, 20, 0), true)...)
+			events = append(events, both(at(0, 8, 0), false)...)
+			events = append(events, both(at(0, 8, 2), true)...)
+			events = append(events, both(at(0, 8, 3), false)...)
+			events = append(events, both(at(0, 18, 0), true)...)
+			events = append(events, both(at(1, 9, 0), false)...)
+			events = append(events, both(at(1, 19, 0), true)...)
+			events = append(events,
+				Event{Time: at(1, 20, 0), MACAddress: bob, Owner: "Bob", Present: true},
+				Event{Time: at(1, 21, 0), MACAddress: other, Present: true},
+				Event{Time: at(1, 21, 30), MACAddress: alice2, Owner: "Alice", Present: true},
+				Event{Time: at(1, 22, 0), MACAddress: alice2, Owner: "Alice"},
+				Event{Time: at(2, 1, 0), MACAddress: alice, Owner: "Alice"},
+			)
+			return
+		}()
+		cottage = func() []Event {
+			events := make([]Event, len(flapping))
+			for i, e := range flapping {
+				e.Zone = "Cottage"
+				events[i] = e
+			}
+			return events
+		}()
+		present = 28*time.Hour + time.Minute
+		stats   = func(name string) Stats {
+			return Stats{
+				Name:             name,
+				Occupancy:        100 * float64(present) / float64(48*time.Hour),
+				Present:          Duration(present),
+				Observed:         Duration(48 * time.Hour),
+				Arrivals:         3,
+				Departures:       3,
+				TypicalArrival:   "18:00",
+				TypicalDeparture: "08:03",
+				LongestAbsences: []Absence{
+					{Start: at(1, 9, 0), End: at(1, 19, 0), Duration: Duration(10 * time.Hour)},
+					{Start: at(0, 8, 3), End: at(0, 18, 0), Duration: Duration(9*time.Hour + 57*time.Minute)},
+					{Start: at(0, 8, 0), End: at(0, 8, 2), Duration: Duration(2 * time.Minute)},
+				},
+				Flaps: 2,
+			}
+		}
+	)
+
+	cases := []struct {
+		name   string
+		events []Event
+		zone   string
+		report *Report
+	}{
+		{
+			name: "empty",
+			report: &Report{
+				Since:     since,
+				Until:     until,
+				Household: Stats{Name: "household", LongestAbsences: []Absence{}},
+				People:    []Stats{},
+			},
+		},
+		{
+			name:   "household and people",
+			events: flapping,
+			report: &Report{
+				Since:     since,
+				Until:     until,
+				Household: stats("household"),
+				People: []Stats{
+					stats("Alice"),
+					{
+						Name:            "Bob",
+						Occupancy:       100,
+						Present:         Duration(4 * time.Hour),
+						Observed:        Duration(4 * time.Hour),
+						LongestAbsences: []Absence{},
+					},
+				},
+			},
+		},
+		{
+			name:   "zone",
+			events: append(append([]Event{}, flapping[:2]...), cottage...),
+			zone:   "cottage",
+			report: &Report{
+				Zone:      "cottage",
+				Since:     since,
+				Until:     until,
+				Household: stats("household"),
+				People: []Stats{
+					stats("Alice"),
+					{
+						Name:            "Bob",
+						Occupancy:       100,
+						Present:         Duration(4 * time.Hour),
+						Observed:        Duration(4 * time.Hour),
+						LongestAbsences: []Absence{},
+					},
+				},
+			},
+		},
+		{
+			name:   "other zones",
+			events: cottage,
+			report: &Report{
+				Since:     since,
+				Until:     until,
+				Household: Stats{Name: "household", LongestAbsences: []Absence{}},
+				People:    []Stats{},
+			},
+		},
+		{
+			name: "ongoing absence",
+			events: []Event{
+				{Time: at(-1, 20, 0), Present: true},
+				{Time: at(1, 12, 0)},
+			},
+			report: &Report{
+				Since: since,
+				Until: until,
+				Household: Stats{
+					Name:             "household",
+					Occupancy:        75,
+					Present:          Duration(36 * time.Hour),
+					Observed:         Duration(48 * time.Hour),
+					Departures:       1,
+					TypicalDeparture: "12:00",
+					LongestAbsences: []Absence{
+						{Start: at(1, 12, 0), End: until, Duration: Duration(12 * time.Hour), Ongoing: true},
+					},
+				},
+				People: []Stats{},
+			},
+		},
+		{
+			name: "after until",
+			events: []Event{
+				{Time: at(0, 12, 0), Present: true},
+				{Time: at(2, 0, 0)},
+			},
+			report: &Report{
+				Since: since,
+				Until: until,
+				Household: Stats{
+					Name:            "household",
+					Occupancy:       100,
+					Present:         Duration(36 * time.Hour),
+					Observed:        Duration(36 * time.Hour),
+					LongestAbsences: []Absence{},
+				},
+				People: []Stats{},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.report, NewReport(tc.events, tc.zone, since, until, 5*time.Minute))
+		})
+	}
+}
+
+func TestDuration_JSON(t *testing.T) {
+	assert := assert.New(t)
+
+	b, err := json.Marshal(Duration(90 * time.Minute))
+	if assert.NoError(err) {
+		assert.Equal(`"1h30m0s"`, string(b))
+	}
+
+	var d Duration
+	if assert.NoError(json.Unmarshal(b, &d)) {
+		assert.Equal(Duration(90*time.Minute), d)
+	}
+	assert.EqualError(json.Unmarshal([]byte(`"soon"`), &d), `time: invalid duration "soon"`)
+}
@@ -0,0 +1,50 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	localLayouts = []string{
+		"2006-01-02",
+		"2006-01-02 15:04",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+	}
+)
+
+// ParseTime parses s as a time relative to now: an RFC 3339 time, a date
+// with an optional time of day in now's location, a duration before now
+// (e.g. 24h), or now, today or yesterday (at midnight).
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		return midnight(now), nil
+	case "yesterday":
+		return midnight(now).AddDate(0, 0, -1), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %#v", s)
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTime(t *testing.T) {
+	var (
+		loc = time.FixedZone("PST", -8*60*60)
+		now = time.Date(2022, time.March, 4, 17, 30, 0, 0, loc)
+	)
+
+	cases := []struct {
+		name, s string
+		time    time.Time
+		err     string
+	}{
+		{name: "now", s: "now", time: now},
+		{name: "today", s: "today", time: time.Date(2022, time.March, 4, 0, 0, 0, 0, loc)},
+		{name: "yesterday", s: "Yesterday", time: time.Date(2022, time.March, 3, 0, 0, 0, 0, loc)},
+		{name: "RFC 3339", s: "2022-03-01T12:00:00Z", time: time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "date", s: "2022-03-01", time: time.Date(2022, time.March, 1, 0, 0, 0, 0, loc)},
+		{name: "date and time", s: "2022-03-01 18:15", time: time.Date(2022, time.March, 1, 18, 15, 0, 0, loc)},
+		{name: "duration", s: "90m", time: time.Date(2022, time.March, 4, 16, 0, 0, 0, loc)},
+		{name: "negative duration", s: "-1h", err: `invalid time "-1h"`},
+		{name: "invalid", s: "last week", err: `invalid time "last week"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+
+			parsed, err := ParseTime(tc.s, now)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.True(tc.time.Equal(parsed), "%v != %v", tc.time, parsed)
+			}
+		})
+	}
+}
@@ -10,18 +10,42 @@ import (
 	"net/url"
 
 	goahttp "goa.design/goa/v3/http"
+
+	"douglasthrift.net/presence/wrap"
 )
 
 type (
 	Client interface {
-		Trigger(ctx context.Context, present bool) (event string, values *Values, err error)
+		Trigger(ctx context.Context, data *Data) (event string, values *Values, err error)
 	}
 
 	client struct {
-		c                                                *http.Client
-		presentEvent, presentURL, absentEvent, absentURL string
-		presentValues, absentValues                      *Values
-		debug                                            bool
+		c                         *http.Client
+		present, absent, flapping *event
+		heartbeat                 *event
+		debug                     bool
+	}
+
+	// Events are the events triggered when someone becomes present, when
+	// everyone becomes absent, when the household or devices start flapping
+	// and for heartbeats. Flapping is not triggered when it has no name and
+	// heartbeats trigger Present or Absent instead when Heartbeat has none.
+	Events struct {
+		Present, Absent, Flapping, Heartbeat Event
+	}
+
+	// Event is the name of an event, its value templates and its rate
+	// limit.
+	Event struct {
+		Name   string
+		Values Values
+		Limit  Limit
+	}
+
+	event struct {
+		name, url string
+		templates *Templates
+		limiter   *limiter
 	}
 
 	Values struct {
@@ -31,56 +55,130 @@ type (
 	}
 )
 
-func NewClient(c *http.Client, baseURL, key, presentEvent, absentEvent string, presentValues, absentValues Values, debug bool) (Client, error) {
-	presentURL, err := url.JoinPath(baseURL, "trigger", presentEvent, "with/key", key)
+// NewClient returns a client triggering the events with the webhooks key,
+// which rate limits them by the clock.
+func NewClient(c *http.Client, baseURL, key string, events Events, clock wrap.Clock, debug bool) (Client, error) {
+	present, err := newEvent(baseURL, key, events.Present, clock)
 	if err != nil {
 		return nil, err
 	}
 
-	absentURL, err := url.JoinPath(baseURL, "trigger", absentEvent, "with/key", key)
+	absent, err := newEvent(baseURL, key, events.Absent, clock)
 	if err != nil {
 		return nil, err
 	}
 
+	var flapping, heartbeat *event
+	if events.Flapping.Name != "" {
+		flapping, err = newEvent(baseURL, key, events.Flapping, clock)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if events.Heartbeat.Name != "" {
+		heartbeat, err = newEvent(baseURL, key, events.Heartbeat, clock)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &client{
-		c:             c,
-		presentEvent:  presentEvent,
-		presentURL:    presentURL,
-		presentValues: &presentValues,
-		absentEvent:   absentEvent,
-		absentURL:     absentURL,
-		absentValues:  &absentValues,
-		debug:         debug,
+		c:         c,
+		present:   present,
+		absent:    absent,
+		flapping:  flapping,
+		heartbeat: heartbeat,
+		debug:     debug,
 	}, nil
 }
 
-func (c *client) Trigger(ctx context.Context, present bool) (string, *Values, error) {
-	var (
-		event, u string
-		values   *Values
-	)
-	if present {
-		event = c.presentEvent
-		u = c.presentURL
-		values = c.presentValues
-	} else {
-		event = c.absentEvent
-		u = c.absentURL
-		values = c.absentValues
+func newEvent(baseURL, key string, e Event, clock wrap.Clock) (*event, error) {
+	u, err := url.JoinPath(baseURL, "trigger", e.Name, "with/key", key)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := e.Values.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	return &event{name: e.Name, url: u, templates: templates, limiter: newLimiter(e.Limit, clock)}, nil
+}
+
+// KeepLimits makes the events of the client to, which replaces the client
+// from (e.g. when reloading the config), carry on with the rate limits of
+// those of from with the same name and limit rather than starting afresh.
+// Clients which were not returned by NewClient are left alone.
+func KeepLimits(from, to Client) {
+	f, ok := from.(*client)
+	if !ok {
+		return
+	}
+	t, ok := to.(*client)
+	if !ok || f == t {
+		return
+	}
+
+	for _, e := range []struct{ from, to *event }{
+		{f.present, t.present},
+		{f.absent, t.absent},
+		{f.flapping, t.flapping},
+		{f.heartbeat, t.heartbeat},
+	} {
+		if e.from != nil && e.to != nil && e.from.name == e.to.name && e.from.limiter.limit == e.to.limiter.limit {
+			e.to.limiter = e.from.limiter
+		}
+	}
+}
+
+func (c *client) Trigger(ctx context.Context, data *Data) (string, *Values, error) {
+	var ev *event
+	switch {
+	case data.Flapping:
+		if c.flapping == nil {
+			return "", nil, fmt.Errorf("no flapping event")
+		}
+		ev = c.flapping
+	case data.Heartbeat && c.heartbeat != nil:
+		ev = c.heartbeat
+	case data.Present:
+		ev = c.present
+	default:
+		ev = c.absent
+	}
+	event, u, templates := ev.name, ev.url, ev.templates
+
+	values, err := templates.Execute(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%v values: %w", event, err)
+	}
+	if err := ev.limiter.allow(event, values); err != nil {
+		return "", nil, err
 	}
+	if err := c.post(ctx, u, values); err != nil {
+		ev.limiter.refund()
+		return "", nil, err
+	}
+
+	ev.limiter.trigger(values)
+	return event, values, nil
+}
 
+// post posts the values to the URL of an event.
+func (c *client) post(ctx context.Context, u string, values *Values) error {
 	var (
 		b = &bytes.Buffer{}
 		e = json.NewEncoder(b)
 	)
 	e.SetEscapeHTML(false)
 	if err := e.Encode(values); err != nil {
-		return "", nil, err
+		return err
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, b)
 	if err != nil {
-		return "", nil, err
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -91,7 +189,7 @@ func (c *client) Trigger(ctx context.Context, present bool) (string, *Values, er
 
 	resp, err := doer.Do(req)
 	if err != nil {
-		return "", nil, err
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -99,13 +197,13 @@ func (c *client) Trigger(ctx context.Context, present bool) (string, *Values, er
 		var b []byte
 		b, err = io.ReadAll(resp.Body)
 		if err != nil {
-			return "", nil, fmt.Errorf("%v: <failed to read body: %w>", resp.Status, err)
+			return fmt.Errorf("%v: <failed to read body: %w>", resp.Status, err)
 		} else if len(b) == 0 {
 			b = []byte("<empty body>")
 		}
 
-		return "", nil, fmt.Errorf("%v: %s", resp.Status, b)
+		return fmt.Errorf("%v: %s", resp.Status, b)
 	}
 
-	return event, values, nil
+	return nil
 }
@@ -7,15 +7,20 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/wrap"
 )
 
 const (
-	baseURL      = "https://maker.ifttt.com"
-	presentEvent = "presence_detected"
-	absentEvent  = "absence_detected"
+	baseURL        = "https://maker.ifttt.com"
+	presentEvent   = "presence_detected"
+	absentEvent    = "absence_detected"
+	flappingEvent  = "presence_flapping"
+	heartbeatEvent = "presence_heartbeat"
 )
 
 var (
@@ -29,13 +34,46 @@ var (
 		Value2: "absence_detected_value2",
 		Value3: "absence_detected_value3",
 	}
+	flappingValues = Values{
+		Value1: "presence_flapping_value1",
+	}
+	heartbeatValues = Values{
+		Value1: "{{.Owner}}",
+	}
+	events = Events{
+		Present:   Event{Name: presentEvent, Values: presentValues},
+		Absent:    Event{Name: absentEvent, Values: absentValues},
+		Flapping:  Event{Name: flappingEvent, Values: flappingValues},
+		Heartbeat: Event{Name: heartbeatEvent, Values: heartbeatValues},
+	}
 )
 
 func TestNewClient(t *testing.T) {
 	t.Run("invalid base URL", func(t *testing.T) {
-		_, err := NewClient(http.DefaultClient, "%", "key", presentEvent, absentEvent, presentValues, absentValues, false)
+		_, err := NewClient(http.DefaultClient, "%", "key", events, wrap.NewClock(), false)
 		assert.ErrorContains(t, err, `parse "%": invalid URL escape "%"`)
 	})
+
+	t.Run("invalid present values", func(t *testing.T) {
+		events := events
+		events.Present.Values = Values{Value1: "{{"}
+		_, err := NewClient(http.DefaultClient, baseURL, "key", events, wrap.NewClock(), false)
+		assert.ErrorContains(t, err, "template: value1:1: unclosed action")
+	})
+
+	t.Run("invalid absent values", func(t *testing.T) {
+		events := events
+		events.Absent.Values = Values{Value3: "{{end}}"}
+		_, err := NewClient(http.DefaultClient, baseURL, "key", events, wrap.NewClock(), false)
+		assert.ErrorContains(t, err, "template: value3:1: unexpected {{end}}")
+	})
+
+	t.Run("invalid flapping values", func(t *testing.T) {
+		events := events
+		events.Flapping.Values = Values{Value2: "{{.Missing"}
+		_, err := NewClient(http.DefaultClient, baseURL, "key", events, wrap.NewClock(), false)
+		assert.ErrorContains(t, err, "template: value2:1: unclosed action")
+	})
 }
 
 func TestClient_Trigger(t *testing.T) {
@@ -44,7 +82,8 @@ func TestClient_Trigger(t *testing.T) {
 	cases := []struct {
 		name, key, event, err string
 		ctx                   context.Context
-		present, noDebug      bool
+		present, flapping     bool
+		heartbeat, noDebug    bool
 		values                Values
 		handler               func(t *testing.T) http.HandlerFunc
 	}{
@@ -98,6 +137,45 @@ func TestClient_Trigger(t *testing.T) {
 			},
 			event: absentEvent,
 		},
+		{
+			name:     "flapping",
+			key:      "key",
+			ctx:      ctx,
+			present:  true,
+			flapping: true,
+			values:   flappingValues,
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assert := assert.New(t)
+
+					assert.Equal("/trigger/"+flappingEvent+"/with/key/key", r.URL.Path)
+
+					body, err := io.ReadAll(r.Body)
+					assert.NoError(err)
+					assert.JSONEq(`{"value1": "presence_flapping_value1"}`, string(body))
+				}
+			},
+			event: flappingEvent,
+		},
+		{
+			name:      "heartbeat",
+			key:       "key",
+			ctx:       ctx,
+			heartbeat: true,
+			values:    Values{Value1: "Alice"},
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assert := assert.New(t)
+
+					assert.Equal("/trigger/"+heartbeatEvent+"/with/key/key", r.URL.Path)
+
+					body, err := io.ReadAll(r.Body)
+					assert.NoError(err)
+					assert.JSONEq(`{"value1": "Alice"}`, string(body))
+				}
+			},
+			event: heartbeatEvent,
+		},
 		{
 			name: "nil context",
 			ctx:  nil,
@@ -179,10 +257,14 @@ func TestClient_Trigger(t *testing.T) {
 			ts := httptest.NewTLSServer(tc.handler(t))
 			defer ts.Close()
 
-			c, err := NewClient(ts.Client(), ts.URL, tc.key, presentEvent, absentEvent, presentValues, absentValues, !tc.noDebug)
+			c, err := NewClient(ts.Client(), ts.URL, tc.key, events, wrap.NewClock(), !tc.noDebug)
 			assert.NoError(err)
 
-			event, values, err := c.Trigger(tc.ctx, tc.present)
+			data := &Data{Present: tc.present, Flapping: tc.flapping, Heartbeat: tc.heartbeat}
+			if tc.heartbeat {
+				data.Owner = "Alice"
+			}
+			event, values, err := c.Trigger(tc.ctx, data)
 			if tc.err != "" {
 				tc.err = strings.ReplaceAll(tc.err, baseURL, ts.URL)
 				assert.EqualError(err, tc.err)
@@ -193,3 +275,70 @@ func TestClient_Trigger(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_Trigger_NoFlappingEvent(t *testing.T) {
+	events := events
+	events.Flapping = Event{}
+	c, err := NewClient(http.DefaultClient, baseURL, "key", events, wrap.NewClock(), false)
+	if assert.NoError(t, err) {
+		_, _, err = c.Trigger(context.Background(), &Data{Flapping: true})
+		assert.EqualError(t, err, "no flapping event")
+	}
+}
+
+func TestClient_Trigger_NoHeartbeatEvent(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("/trigger/"+absentEvent+"/with/key/key", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	events := events
+	events.Heartbeat = Event{}
+	c, err := NewClient(ts.Client(), ts.URL, "key", events, wrap.NewClock(), false)
+	if assert.NoError(err) {
+		event, _, err := c.Trigger(context.Background(), &Data{Heartbeat: true})
+		assert.NoError(err)
+		assert.Equal(absentEvent, event)
+	}
+}
+
+func TestKeepLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	limited := func(limit Limit) Client {
+		events := events
+		events.Present.Limit = limit
+		c, err := NewClient(ts.Client(), ts.URL, "key", events, wrap.NewClock(), false)
+		assert.NoError(err)
+		return c
+	}
+	data := &Data{Present: true}
+
+	old := limited(Limit{Events: 1, Per: time.Hour})
+	_, _, err := old.Trigger(context.Background(), data)
+	assert.NoError(err)
+
+	// The same limit carries on from the old client.
+	c := limited(Limit{Events: 1, Per: time.Hour})
+	KeepLimits(old, c)
+	_, _, err = c.Trigger(context.Background(), data)
+	var rle *RateLimitError
+	if assert.ErrorAs(err, &rle) {
+		assert.Equal(presentEvent, rle.Event)
+	}
+
+	// A changed limit starts afresh.
+	c = limited(Limit{Events: 2, Per: time.Hour})
+	KeepLimits(old, c)
+	_, _, err = c.Trigger(context.Background(), data)
+	assert.NoError(err)
+
+	// Other clients are left alone.
+	KeepLimits(nil, c)
+	KeepLimits(old, nil)
+}
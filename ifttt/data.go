@@ -0,0 +1,133 @@
+package ifttt
+
+import (
+	"bytes"
+	"text/template"
+	"time"
+)
+
+type (
+	// Data is the context the event value templates are evaluated with
+	// when an event is triggered.
+	Data struct {
+		// Timestamp is the time the event was triggered.
+		Timestamp time.Time
+		// Hostname is the name of the host running the daemon.
+		Hostname string
+		// Present is whether anyone is present, or whether Owner is.
+		Present bool
+		// Duration is how long it has been since the household state last
+		// changed (i.e. how long it was empty before someone arrived or
+		// occupied before everyone left). It is zero when unknown.
+		Duration time.Duration
+		// Devices are all of the tracked devices.
+		Devices []Device
+		// Changed are the devices whose state changed. Devices which are
+		// flapping are left out.
+		Changed []Device
+		// Flapping is whether the event warns that the household or the
+		// Changed devices started flapping.
+		Flapping bool
+		// Heartbeat is whether the event is a heartbeat triggered while the
+		// state holds.
+		Heartbeat bool
+		// Owner is the person a heartbeat is for, or empty for the
+		// household.
+		Owner string
+		// Zone is the name of the zone the event is for, or empty for the
+		// household of the config.
+		Zone string
+	}
+
+	// Device is the state of a tracked device.
+	Device struct {
+		MACAddress string
+		// Name, Owner, Type, Icon and Tags describe the device when they
+		// are configured.
+		Name, Owner, Type, Icon string
+		Tags                    []string
+		Present                 bool
+		// Interface is the network interface the device was last seen on.
+		Interface string
+	}
+
+	// Templates are the parsed templates for the values of an event.
+	Templates struct {
+		value1, value2, value3 *template.Template
+	}
+)
+
+// PresentDevices returns the devices which are present.
+func (d *Data) PresentDevices() []Device {
+	ds := make([]Device, 0, len(d.Devices))
+	for _, dev := range d.Devices {
+		if dev.Present {
+			ds = append(ds, dev)
+		}
+	}
+	return ds
+}
+
+// String returns the device name, or its MAC address when it has none.
+func (d Device) String() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.MACAddress
+}
+
+// Parse parses each of the values as a template.
+func (v Values) Parse() (*Templates, error) {
+	value1, err := template.New("value1").Parse(v.Value1)
+	if err != nil {
+		return nil, err
+	}
+
+	value2, err := template.New("value2").Parse(v.Value2)
+	if err != nil {
+		return nil, err
+	}
+
+	value3, err := template.New("value3").Parse(v.Value3)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Templates{
+		value1: value1,
+		value2: value2,
+		value3: value3,
+	}, nil
+}
+
+// Execute evaluates each of the templates with data.
+func (t *Templates) Execute(data *Data) (*Values, error) {
+	value1, err := execute(t.value1, data)
+	if err != nil {
+		return nil, err
+	}
+
+	value2, err := execute(t.value2, data)
+	if err != nil {
+		return nil, err
+	}
+
+	value3, err := execute(t.value3, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Values{
+		Value1: value1,
+		Value2: value2,
+		Value3: value3,
+	}, nil
+}
+
+func execute(t *template.Template, data *Data) (string, error) {
+	b := &bytes.Buffer{}
+	if err := t.Execute(b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,102 @@
+package ifttt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestData_PresentDevices(t *testing.T) {
+	var (
+		a = Device{MACAddress: "00:00:00:00:00:01", Present: true, Interface: "eth0"}
+		b = Device{MACAddress: "00:00:00:00:00:02"}
+		c = Device{MACAddress: "00:00:00:00:00:03", Present: true, Interface: "eth1"}
+	)
+
+	d := &Data{Devices: []Device{a, b, c}}
+	assert.Equal(t, []Device{a, c}, d.PresentDevices())
+}
+
+func TestDevice_String(t *testing.T) {
+	assert.Equal(t, "Alice's Pixel", Device{MACAddress: "00:00:00:00:00:01", Name: "Alice's Pixel"}.String())
+	assert.Equal(t, "00:00:00:00:00:01", Device{MACAddress: "00:00:00:00:00:01", Owner: "Alice"}.String())
+}
+
+func TestTemplates_Execute(t *testing.T) {
+	data := &Data{
+		Timestamp: time.Date(2022, time.March, 4, 5, 6, 7, 0, time.UTC),
+		Hostname:  "router",
+		Present:   true,
+		Duration:  90 * time.Minute,
+		Devices: []Device{
+			{MACAddress: "00:00:00:00:00:01", Present: true, Interface: "eth0"},
+			{MACAddress: "00:00:00:00:00:02", Name: "Pixel", Owner: "Alice"},
+		},
+		Changed: []Device{
+			{MACAddress: "00:00:00:00:00:01", Present: true, Interface: "eth0"},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		values Values
+		exp    *Values
+		err    string
+	}{
+		{
+			name:   "static",
+			values: Values{Value1: "one", Value2: "two", Value3: "three"},
+			exp:    &Values{Value1: "one", Value2: "two", Value3: "three"},
+		},
+		{
+			name: "data",
+			values: Values{
+				Value1: `{{.Hostname}} {{.Timestamp.Format "2006-01-02 15:04"}}`,
+				Value2: `{{range .Changed}}{{.}} on {{.Interface}}{{end}} after {{.Duration}}`,
+				Value3: `{{len .PresentDevices}}/{{len .Devices}} present: {{.Present}}`,
+			},
+			exp: &Values{
+				Value1: "router 2022-03-04 05:06",
+				Value2: "00:00:00:00:00:01 on eth0 after 1h30m0s",
+				Value3: "1/2 present: true",
+			},
+		},
+		{
+			name: "device metadata",
+			values: Values{
+				Value1: `{{range .Devices}}{{if .Owner}}{{.Owner}}'s {{.}}{{end}}{{end}}`,
+			},
+			exp: &Values{
+				Value1: "Alice's Pixel",
+			},
+		},
+		{
+			name:   "execute error",
+			values: Values{Value2: "{{.Nonexistent}}"},
+			err:    `template: value2:1:2: executing "value2" at <.Nonexistent>: can't evaluate field Nonexistent in type *ifttt.Data`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			ts, err := tc.values.Parse()
+			if !assert.NoError(err) {
+				return
+			}
+
+			values, err := ts.Execute(data)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.exp, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package ifttt
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// Limit is the rate limit of an event. Zero fields are unlimited.
+	Limit struct {
+		// Events is how many times the event may be triggered per Per,
+		// with bursts of up to Events.
+		Events uint
+		Per    time.Duration
+		// MinInterval is how long after the event was triggered it may not
+		// be triggered again with identical values.
+		MinInterval time.Duration
+	}
+
+	// RateLimitError is returned when an event is not triggered because of
+	// its rate limit.
+	RateLimitError struct {
+		Event string
+		// Identical is whether the event was just triggered with identical
+		// values.
+		Identical bool
+		// RetryAfter is how long until the event may be triggered.
+		RetryAfter time.Duration
+	}
+
+	// limiter is a token bucket which also remembers the last values
+	// triggered.
+	limiter struct {
+		limit Limit
+		clock wrap.Clock
+
+		mu        sync.Mutex
+		tokens    float64
+		refilled  time.Time
+		values    Values
+		triggered time.Time
+	}
+)
+
+func (e *RateLimitError) Error() string {
+	reason := "rate limited"
+	if e.Identical {
+		reason = "identical values"
+	}
+	return fmt.Sprintf("%v not triggered: %v (retry after %v)", e.Event, reason, e.RetryAfter)
+}
+
+func newLimiter(limit Limit, clock wrap.Clock) *limiter {
+	return &limiter{
+		limit:  limit,
+		clock:  clock,
+		tokens: float64(limit.Events),
+	}
+}
+
+// allow takes a token for triggering the event with the values or returns
+// a rate limit error.
+func (l *limiter) allow(event string, values *Values) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if l.limit.MinInterval != 0 && !l.triggered.IsZero() && *values == l.values {
+		if wait := l.triggered.Add(l.limit.MinInterval).Sub(now); wait > 0 {
+			return &RateLimitError{Event: event, Identical: true, RetryAfter: wait}
+		}
+	}
+
+	if l.limit.Events == 0 || l.limit.Per <= 0 {
+		return nil
+	}
+
+	interval := l.limit.Per / time.Duration(l.limit.Events)
+	if !l.refilled.IsZero() {
+		l.tokens += float64(now.Sub(l.refilled)) / float64(interval)
+		l.tokens = min(l.tokens, float64(l.limit.Events))
+	}
+	l.refilled = now
+
+	if l.tokens < 1 {
+		return &RateLimitError{Event: event, RetryAfter: time.Duration((1 - l.tokens) * float64(interval))}
+	}
+	l.tokens--
+	return nil
+}
+
+// refund gives back the token taken by allow when triggering the event
+// failed, so that failures do not use up the rate limit.
+func (l *limiter) refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit.Events == 0 || l.limit.Per <= 0 {
+		return
+	}
+	l.tokens = min(l.tokens+1, float64(l.limit.Events))
+}
+
+// trigger records that the event was triggered with the values.
+func (l *limiter) trigger(values *Values) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.values = *values
+	l.triggered = l.clock.Now()
+}
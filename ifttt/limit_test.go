@@ -0,0 +1,154 @@
+package ifttt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/wrap"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	var (
+		start = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+		a     = &Values{Value1: "a"}
+		b     = &Values{Value1: "b"}
+	)
+
+	type attempt struct {
+		after  time.Duration
+		values *Values
+		failed bool
+		err    error
+	}
+
+	cases := []struct {
+		name     string
+		limit    Limit
+		attempts []attempt
+	}{
+		{
+			name:  "unlimited",
+			limit: Limit{},
+			attempts: []attempt{
+				{values: a},
+				{values: a},
+				{values: a},
+			},
+		},
+		{
+			name:  "token bucket",
+			limit: Limit{Events: 2, Per: time.Hour},
+			attempts: []attempt{
+				{values: a},
+				{values: b},
+				{after: 10 * time.Minute, values: a, err: &RateLimitError{Event: "event", RetryAfter: 20 * time.Minute}},
+				{after: 30 * time.Minute, values: a},
+				{after: 30 * time.Minute, values: b, err: &RateLimitError{Event: "event", RetryAfter: 30 * time.Minute}},
+				{after: 3 * time.Hour, values: a},
+				{after: 3 * time.Hour, values: b},
+				{after: 3 * time.Hour, values: a, err: &RateLimitError{Event: "event", RetryAfter: 30 * time.Minute}},
+			},
+		},
+		{
+			name:  "failures",
+			limit: Limit{Events: 1, Per: time.Hour},
+			attempts: []attempt{
+				{values: a, failed: true},
+				{values: a, failed: true},
+				{values: a},
+				{values: a, err: &RateLimitError{Event: "event", RetryAfter: time.Hour}},
+			},
+		},
+		{
+			name:  "min interval",
+			limit: Limit{MinInterval: 5 * time.Minute},
+			attempts: []attempt{
+				{values: a},
+				{after: time.Minute, values: a, err: &RateLimitError{Event: "event", Identical: true, RetryAfter: 4 * time.Minute}},
+				{after: 2 * time.Minute, values: b},
+				{after: 3 * time.Minute, values: a},
+				{after: 8 * time.Minute, values: a},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			clock := wrap.NewFakeClock(start)
+			l := newLimiter(tc.limit, clock)
+			for i, a := range tc.attempts {
+				clock.Set(start.Add(a.after))
+				err := l.allow("event", a.values)
+				if a.err != nil {
+					assert.Equal(t, a.err, err, "attempt %d", i)
+					continue
+				}
+				if assert.NoError(t, err, "attempt %d", i) {
+					if a.failed {
+						l.refund()
+					} else {
+						l.trigger(a.values)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitError_Error(t *testing.T) {
+	assert.EqualError(t, &RateLimitError{Event: "presence_detected", RetryAfter: time.Minute},
+		"presence_detected not triggered: rate limited (retry after 1m0s)")
+	assert.EqualError(t, &RateLimitError{Event: "presence_detected", Identical: true, RetryAfter: time.Second},
+		"presence_detected not triggered: identical values (retry after 1s)")
+}
+
+func TestClient_Trigger_RateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	requests := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	events := events
+	events.Present.Limit = Limit{Events: 1, Per: time.Hour}
+	clock := wrap.NewFakeClock(time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC))
+	c, err := NewClient(ts.Client(), ts.URL, "key", events, clock, false)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Failing to trigger does not use up the rate limit.
+	_, _, err = c.Trigger(context.Background(), &Data{Present: true})
+	assert.EqualError(err, "503 Service Unavailable: <empty body>")
+
+	_, _, err = c.Trigger(context.Background(), &Data{Present: true})
+	assert.NoError(err)
+
+	_, _, err = c.Trigger(context.Background(), &Data{Present: true})
+	var rle *RateLimitError
+	if assert.True(errors.As(err, &rle)) {
+		assert.Equal(presentEvent, rle.Event)
+		assert.False(rle.Identical)
+	}
+
+	_, _, err = c.Trigger(context.Background(), &Data{})
+	assert.NoError(err)
+
+	clock.Advance(time.Hour)
+	_, _, err = c.Trigger(context.Background(), &Data{Present: true})
+	assert.NoError(err)
+	assert.Equal(4, requests)
+}
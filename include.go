@@ -0,0 +1,182 @@
+package presence
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+var (
+	fragmentExts = map[string]bool{
+		".yml":  true,
+		".yaml": true,
+		".json": true,
+		".toml": true,
+	}
+)
+
+// ConfigDir returns the directory of config fragments merged into the config
+// file name (e.g. presence.d for presence.yml).
+func ConfigDir(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".d"
+}
+
+// ConfigPatterns returns glob patterns matching every file the config file
+// name was or could be loaded from, including the IFTTT key file.
+func ConfigPatterns(name string, c *Config) []string {
+	var patterns []string
+	if name != "" {
+		patterns = append(patterns, name)
+		for _, i := range c.Include {
+			patterns = append(patterns, includePattern(name, i))
+		}
+		patterns = append(patterns, filepath.Join(ConfigDir(name), "*"))
+	}
+	if c.IFTTT.KeyFile != "" {
+		patterns = append(patterns, c.IFTTT.KeyFile)
+	}
+	return patterns
+}
+
+func includePattern(name, include string) string {
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(name), include)
+}
+
+// loadConfig decodes the config file name into c and merges the fragments
+// from its include globs and then its config directory into it, recording
+// the file each field was set from.
+func loadConfig(name string, c *Config, src sources) error {
+	m, err := readConfig(name, c)
+	if err != nil {
+		return err
+	}
+	src.file(name, "", m)
+	src.elements(c)
+
+	files, err := fragments(name, c.Include)
+	if err != nil {
+		return err
+	}
+
+	macs := make(map[string]string, len(c.MACAddresses))
+	for _, d := range c.MACAddresses {
+		if hw, err := net.ParseMAC(d.MACAddress); err == nil {
+			macs[hw.String()] = name
+		}
+	}
+
+	for _, f := range files {
+		fc := &Config{}
+		m, err := readConfig(f, fc)
+		if err != nil {
+			return err
+		}
+
+		if len(fc.Include) != 0 {
+			return fmt.Errorf("%v: include is only allowed in %v", f, name)
+		}
+
+		for _, d := range fc.MACAddresses {
+			hw, err := net.ParseMAC(d.MACAddress)
+			if err != nil {
+				return fmt.Errorf("%v: %w", f, err)
+			}
+
+			a := hw.String()
+			if other, ok := macs[a]; ok {
+				return fmt.Errorf("%v: duplicate MAC address (%v) also in %v", f, a, other)
+			}
+			macs[a] = f
+		}
+
+		fs := sources{}
+		fs.file(f, "", m)
+		mergeConfig(c, fc, fs)
+		for p, s := range fs {
+			src[p] = s
+		}
+	}
+
+	return nil
+}
+
+func readConfig(name string, c *Config) (map[string]any, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := decodeConfig(name, b, c)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return m, nil
+}
+
+// fragments returns the files matching the include globs followed by those
+// in the config directory, each in lexical order.
+func fragments(name string, include []string) ([]string, error) {
+	var (
+		files []string
+		seen  = map[string]bool{name: true}
+	)
+	add := func(pattern string, onlyConfigs bool) error {
+		ms, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("include %#v: %w", pattern, err)
+		}
+
+		for _, m := range ms {
+			if seen[m] || onlyConfigs && !fragmentExts[strings.ToLower(filepath.Ext(m))] {
+				continue
+			}
+			if fi, err := os.Stat(m); err != nil || fi.IsDir() {
+				continue
+			}
+			seen[m] = true
+			files = append(files, m)
+		}
+		return nil
+	}
+
+	for _, i := range include {
+		if err := add(includePattern(name, i), false); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(filepath.Join(ConfigDir(name), "*"), true); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// mergeConfig merges the fields set in the fragment src into dst, appending
+// lists and replacing everything else.
+func mergeConfig(dst, src *Config, set sources) {
+	var (
+		dfs = configFields("", reflect.ValueOf(dst).Elem(), nil)
+		sfs = configFields("", reflect.ValueOf(src).Elem(), nil)
+	)
+	for i, sf := range sfs {
+		if _, ok := set[sf.path]; !ok {
+			continue
+		}
+
+		d := dfs[i].value
+		if d.Kind() == reflect.Slice {
+			for j := range sf.value.Len() {
+				set.element(sf.path, d.Len()+j, set[sf.path])
+			}
+			d.Set(reflect.AppendSlice(d, sf.value))
+		} else {
+			d.Set(sf.value)
+		}
+	}
+}
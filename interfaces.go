@@ -0,0 +1,108 @@
+package presence
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"douglasthrift.net/presence/neighbors"
+)
+
+var (
+	interfaceType = reflect.TypeOf(Interface{})
+)
+
+type (
+	// plainInterface has the fields of Interface without its unmarshalers.
+	plainInterface Interface
+)
+
+// UnmarshalYAML decodes the interface from its name or an object. JSON
+// config files are decoded the same way.
+func (i *Interface) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return n.Decode(&i.Name)
+	}
+	return decodeYAMLObject(n, (*plainInterface)(i), interfaceType)
+}
+
+// UnmarshalTOML decodes the interface from its name or a table.
+func (i *Interface) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		i.Name = v
+		return nil
+	case map[string]any:
+		return decodeTOMLTable(v, (*plainInterface)(i), "interface")
+	default:
+		return fmt.Errorf("expected an interface name or table, got %T", v)
+	}
+}
+
+// UnmarshalText decodes the interface from its name so that interfaces can
+// be set by environment variables and overrides.
+func (i *Interface) UnmarshalText(b []byte) error {
+	*i = Interface{Name: string(b)}
+	return nil
+}
+
+// NeighborsInterfaces returns how neighbors on the network interfaces
+// matching each of the validated config's interfaces are probed.
+func (c *Config) NeighborsInterfaces() neighbors.Interfaces {
+	exclude, _ := neighbors.ParseLinks(c.ExcludeLinks)
+	ifs := make(neighbors.Interfaces, 0, len(c.Interfaces))
+	for _, i := range c.Interfaces {
+		ifs = append(ifs, i.Neighbors(exclude))
+	}
+	return ifs
+}
+
+// Neighbors returns how neighbors on the network interfaces matching the
+// validated interface are probed, excluding links when it is a pattern.
+func (i Interface) Neighbors(exclude neighbors.Links) neighbors.Interface {
+	probe, _ := neighbors.ParseProbe(i.Probe)
+	ni := neighbors.Interface{
+		Name:      i.Name,
+		Exclude:   exclude,
+		PingCount: i.PingCount,
+		Probe:     probe,
+		Timeout:   i.Timeout,
+	}
+	for _, a := range i.AllowedIPs {
+		if p, err := neighbors.ParsePrefix(a); err == nil {
+			ni.AllowedIPs = append(ni.AllowedIPs, p)
+		}
+	}
+	return ni
+}
+
+func (i Interface) validate() error {
+	if neighbors.IsPattern(i.Name) {
+		if err := neighbors.CompilePattern(i.Name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := neighbors.ParseProbe(i.Probe); err != nil {
+		return err
+	}
+
+	ps := make(map[netip.Prefix]bool, len(i.AllowedIPs))
+	for _, a := range i.AllowedIPs {
+		p, err := neighbors.ParsePrefix(a)
+		if err != nil {
+			return fmt.Errorf("allowed IPs: %w", err)
+		}
+		if ps[p] {
+			return fmt.Errorf("allowed IPs: duplicate range (%v)", p)
+		}
+		ps[p] = true
+	}
+
+	if i.Timeout < 0 {
+		return fmt.Errorf("negative timeout (%v)", i.Timeout)
+	}
+	return nil
+}
@@ -16,6 +16,11 @@ func Generate() (err error) {
 	return sh.RunV("go", "tool", "cmg", "gen", "-testify", "./...")
 }
 
+// Proto generates the gRPC API from its protocol buffers with buf.
+func Proto() error {
+	return sh.RunV("buf", "generate", "api", "--template", "api/buf.gen.yaml", "--output", "api")
+}
+
 // Build builds the binaries.
 func Build() error {
 	return sh.RunV("go", "build", "./cmd/presence")
@@ -2,14 +2,17 @@ package neighbors
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"os/exec"
+	"slices"
 
 	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/wrap"
 )
 
 type (
-	Interfaces         map[string]bool
 	HardwareAddrStates map[string]State
 
 	ARP interface {
@@ -19,7 +22,17 @@ type (
 
 	arp struct {
 		cmd    string
+		count  uint
 		arping ARPing
+		// pinger is nil when there is no ping command, which is only an
+		// error for interfaces using [ProbePing].
+		pinger    Pinger
+		pingerErr error
+		net       wrap.Net
+		// names are the network interfaces resolved last time for each
+		// set of interfaces (e.g. those of each zone) so that changes in
+		// them can be logged.
+		names map[string][]string
 	}
 )
 
@@ -34,26 +47,41 @@ func NewARP(count uint) (ARP, error) {
 		return nil, err
 	}
 
+	pinger, pingerErr := NewPinger()
+
 	return &arp{
-		cmd:    cmd,
-		arping: arping,
+		cmd:       cmd,
+		count:     count,
+		arping:    arping,
+		pinger:    pinger,
+		pingerErr: pingerErr,
+		net:       wrap.NewNet(),
+		names:     make(map[string][]string),
 	}, nil
 }
 
 func (a *arp) Present(ctx context.Context, ifs Interfaces, state State, addrStates HardwareAddrStates) (err error) {
-	as := make(map[string]bool, len(addrStates))
+	var (
+		as   = make(map[string]bool, len(addrStates))
+		ifis = make(map[string]string, len(addrStates))
+	)
 	for hw := range addrStates {
 		as[hw] = false
 	}
 
-	es, err := a.entries(ctx, ifs)
+	resolved, err := a.resolve(ctx, ifs)
+	if err != nil {
+		return
+	}
+
+	es, err := a.table(ctx, resolved)
 	if err != nil {
 		return
 	}
 
 	for _, e := range es {
 		log.Debug(ctx, log.KV{K: "IP address", V: e.IPAddress}, log.KV{K: "MAC address", V: e.MACAddress}, log.KV{K: "interface", V: e.Interface})
-		if ifs[e.Interface] {
+		if ifi, ok := resolved[e.Interface]; ok {
 			var hwa net.HardwareAddr
 			hwa, err = net.ParseMAC(e.MACAddress)
 			if err != nil {
@@ -62,11 +90,20 @@ func (a *arp) Present(ctx context.Context, ifs Interfaces, state State, addrStat
 			hw := hwa.String()
 
 			if _, ok := as[hw]; ok {
-				ok, err = a.arping.Ping(ctx, e.Interface, hw, e.IPAddress)
+				if !ifi.Allowed(e.IPAddress) {
+					log.Debug(ctx, log.KV{K: "msg", V: "IP address not allowed"}, log.KV{K: "device", V: addrStates[hw].Device().Label(hw)},
+						log.KV{K: "IP address", V: e.IPAddress}, log.KV{K: "interface", V: e.Interface})
+					continue
+				}
+
+				ok, err = a.probe(ctx, e.Interface, ifi, hw, e.IPAddress)
 				if err != nil {
 					return
 				}
 				as[hw] = ok
+				if ok {
+					ifis[hw] = e.Interface
+				}
 			}
 		}
 	}
@@ -75,6 +112,7 @@ func (a *arp) Present(ctx context.Context, ifs Interfaces, state State, addrStat
 	for hw, ok := range as {
 		addrStates[hw].Set(ok)
 		if ok {
+			addrStates[hw].SeenOn(ifis[hw])
 			present = true
 		}
 	}
@@ -84,5 +122,71 @@ func (a *arp) Present(ctx context.Context, ifs Interfaces, state State, addrStat
 }
 
 func (a *arp) Count(count uint) {
+	a.count = count
 	a.arping.Count(count)
 }
+
+// resolve matches the interfaces against the network interfaces which
+// currently exist, so that interfaces appearing or disappearing are picked
+// up on the next detection. Interfaces which are not patterns are skipped
+// until they exist.
+func (a *arp) resolve(ctx context.Context, ifs Interfaces) (map[string]Interface, error) {
+	var all []net.Interface
+	if ifs.HasPatterns() {
+		var err error
+		all, err = a.net.Interfaces()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resolved := ifs.Resolve(all)
+	for name, ifi := range resolved {
+		if IsPattern(ifi.Name) {
+			continue
+		}
+		if _, err := a.net.InterfaceByName(name); err != nil {
+			log.Debug(ctx, log.KV{K: "msg", V: "interface not found"}, log.KV{K: "interface", V: name}, log.KV{K: "err", V: err})
+			delete(resolved, name)
+		}
+	}
+	names := make([]string, 0, len(resolved))
+	for n := range resolved {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+
+	key := ifs.key()
+	if old := a.names[key]; !slices.Equal(names, old) {
+		log.Print(ctx, log.KV{K: "msg", V: "interfaces changed"}, log.KV{K: "old", V: old}, log.KV{K: "new", V: names})
+		a.names[key] = names
+	}
+	return resolved, nil
+}
+
+// probe confirms that the neighbor with MAC address hw and IP address ip is
+// present using the probe for the interface.
+func (a *arp) probe(ctx context.Context, name string, ifi Interface, hw, ip string) (bool, error) {
+	if ifi.Timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ifi.Timeout)
+		defer cancel()
+	}
+
+	switch ifi.Probe {
+	case ProbeNone:
+		return true, nil
+	case ProbePing:
+		if a.pinger == nil {
+			return false, fmt.Errorf("interface %v: ping probe: %w", name, a.pingerErr)
+		}
+
+		count := ifi.PingCount
+		if count == 0 {
+			count = a.count
+		}
+		return a.pinger.Ping(ctx, name, ip, count)
+	default:
+		return a.arping.Ping(ctx, name, hw, ip, ifi.PingCount)
+	}
+}
@@ -20,7 +20,7 @@ type (
 	}
 )
 
-func (a *arp) entries(ctx context.Context, ifs Interfaces) (entries []arpEntry, err error) {
+func (a *arp) entries(ctx context.Context, ifs map[string]Interface) (entries []arpEntry, err error) {
 	cmd := exec.CommandContext(ctx, a.cmd, "-family", "inet", "-json", "neighbor", "show", "nud", "reachable")
 	if len(ifs) == 1 {
 		for ifi := range ifs {
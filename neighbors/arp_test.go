@@ -0,0 +1,46 @@
+package neighbors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mockwrap "douglasthrift.net/presence/wrap/mocks"
+)
+
+func TestARP_Resolve(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		wNet   = mockwrap.NewNet(t)
+		a      = &arp{net: wNet, names: make(map[string][]string)}
+		ifs    = Interfaces{{Name: "eth0"}, {Name: "usb0", Probe: ProbePing}, {Name: "wlan*"}}
+		exists = false
+	)
+	wNet.SetInterfaces(func() ([]net.Interface, error) {
+		return []net.Interface{{Name: "eth0", Flags: net.FlagUp}, {Name: "wlan0", Flags: net.FlagUp}}, nil
+	})
+	wNet.SetInterfaceByName(func(name string) (*net.Interface, error) {
+		if name == "usb0" && !exists {
+			return nil, fmt.Errorf("route ip+net: no such network interface")
+		}
+		return &net.Interface{Name: name}, nil
+	})
+
+	// Interfaces which are not patterns are skipped until they exist.
+	resolved, err := a.resolve(ctx, ifs)
+	if assert.NoError(err) {
+		assert.Equal(map[string]Interface{"eth0": ifs[0], "wlan0": ifs[2]}, resolved)
+	}
+
+	exists = true
+	resolved, err = a.resolve(ctx, ifs)
+	if assert.NoError(err) {
+		assert.Equal(map[string]Interface{"eth0": ifs[0], "usb0": ifs[1], "wlan0": ifs[2]}, resolved)
+	}
+	assert.Equal([]string{"eth0", "usb0", "wlan0"}, a.names[ifs.key()])
+}
@@ -14,7 +14,7 @@ import (
 
 type (
 	ARPing interface {
-		Ping(ctx context.Context, ifi, hw, ip string) (bool, error)
+		Ping(ctx context.Context, ifi, hw, ip string, count uint) (bool, error)
 		Count(count uint)
 	}
 
@@ -66,8 +66,14 @@ func NewARPing(count uint) (ARPing, error) {
 	}, nil
 }
 
-func (a *arping) Ping(ctx context.Context, ifi, hw, ip string) (ok bool, err error) {
-	cmd := exec.CommandContext(ctx, a.sudoCmd, a.arpingCmd, "-c", a.count, "-i", ifi, "-t", hw, "-q", ip)
+// Ping sends count ARP pings, or the configured count when count is zero.
+func (a *arping) Ping(ctx context.Context, ifi, hw, ip string, count uint) (ok bool, err error) {
+	c := a.count
+	if count != 0 {
+		c = fmt.Sprint(count)
+	}
+
+	cmd := exec.CommandContext(ctx, a.sudoCmd, a.arpingCmd, "-c", c, "-i", ifi, "-t", hw, "-q", ip)
 	log.Debug(ctx, log.KV{K: "cmd", V: cmd})
 	err = cmd.Run()
 	if err == nil {
@@ -0,0 +1,90 @@
+package neighbors
+
+import (
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+type (
+	// Interface is how neighbors on a network interface, or on every
+	// network interface matching a pattern, are probed.
+	Interface struct {
+		// Name is the name of the network interface, a glob pattern or a
+		// regular expression between slashes (e.g. /^eth[0-9]+$/).
+		Name string
+		// Exclude are the kinds of links skipped when Name is a pattern.
+		Exclude Links
+		// PingCount is the number of pings sent to each neighbor, or zero
+		// for the ARP count.
+		PingCount uint
+		Probe     Probe
+		// AllowedIPs are the ranges of neighbor IP addresses probed, or
+		// empty for all of them.
+		AllowedIPs []netip.Prefix
+		// Timeout limits how long each probe may take, or zero for no limit.
+		Timeout time.Duration
+	}
+
+	// Probe is how a neighbor is confirmed to be present.
+	Probe string
+)
+
+const (
+	// ProbeARPing sends ARP pings to the neighbor's MAC and IP addresses.
+	ProbeARPing Probe = "arping"
+	// ProbePing sends ICMP echo requests to the neighbor's IP address.
+	ProbePing Probe = "ping"
+	// ProbeNone trusts the neighbor table without sending anything.
+	ProbeNone Probe = "none"
+)
+
+// Probes are all of the valid probes.
+var Probes = []Probe{ProbeARPing, ProbePing, ProbeNone}
+
+// ParseProbe returns the probe named s, or [ProbeARPing] when s is empty.
+func ParseProbe(s string) (Probe, error) {
+	if s == "" {
+		return ProbeARPing, nil
+	}
+	for _, p := range Probes {
+		if Probe(s) == p {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid probe %#v", s)
+}
+
+// ParsePrefix parses an IP address range in CIDR notation or a single IP
+// address.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked(), nil
+	}
+
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address or range %#v", s)
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// Allowed returns whether the neighbor IP address ip is in the allowed
+// ranges.
+func (i Interface) Allowed(ip string) bool {
+	if len(i.AllowedIPs) == 0 {
+		return true
+	}
+
+	a, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	a = a.Unmap()
+	for _, p := range i.AllowedIPs {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
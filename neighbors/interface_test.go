@@ -0,0 +1,88 @@
+package neighbors
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProbe(t *testing.T) {
+	cases := []struct {
+		name, s string
+		probe   Probe
+		err     string
+	}{
+		{name: "default", probe: ProbeARPing},
+		{name: "arping", s: "arping", probe: ProbeARPing},
+		{name: "ping", s: "ping", probe: ProbePing},
+		{name: "none", s: "none", probe: ProbeNone},
+		{name: "invalid", s: "icmp", err: `invalid probe "icmp"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+
+			probe, err := ParseProbe(tc.s)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tc.probe, probe)
+			}
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	cases := []struct {
+		name, s string
+		prefix  netip.Prefix
+		err     string
+	}{
+		{name: "CIDR", s: "192.168.1.0/24", prefix: netip.MustParsePrefix("192.168.1.0/24")},
+		{name: "CIDR masked", s: "192.168.1.7/24", prefix: netip.MustParsePrefix("192.168.1.0/24")},
+		{name: "IPv4 address", s: "192.168.1.7", prefix: netip.MustParsePrefix("192.168.1.7/32")},
+		{name: "IPv6 address", s: "fe80::1", prefix: netip.MustParsePrefix("fe80::1/128")},
+		{name: "invalid", s: "192.168.1", err: `invalid IP address or range "192.168.1"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+
+			prefix, err := ParsePrefix(tc.s)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tc.prefix, prefix)
+			}
+		})
+	}
+}
+
+func TestInterface_Allowed(t *testing.T) {
+	cases := []struct {
+		name       string
+		allowedIPs []netip.Prefix
+		ip         string
+		allowed    bool
+	}{
+		{name: "all", ip: "10.0.0.1", allowed: true},
+		{name: "in range", allowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}, ip: "192.168.1.7", allowed: true},
+		{name: "out of range", allowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}, ip: "10.0.0.1"},
+		{name: "second range", allowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("10.0.0.0/8")}, ip: "10.0.0.1", allowed: true},
+		{name: "invalid", allowedIPs: []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")}, ip: "bogus"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.allowed, Interface{AllowedIPs: tc.allowedIPs}.Allowed(tc.ip))
+		})
+	}
+}
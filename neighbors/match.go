@@ -0,0 +1,164 @@
+package neighbors
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type (
+	// Interfaces are matched against network interfaces in order.
+	Interfaces []Interface
+
+	// Links are kinds of network interfaces.
+	Links uint
+)
+
+const (
+	LinkLoopback Links = 1 << iota
+	LinkPointToPoint
+	LinkDown
+
+	// LinksNone excludes no links.
+	LinksNone Links = 0
+	// LinksDefault excludes the links which are unsuitable for detecting
+	// presence.
+	LinksDefault = LinkLoopback | LinkPointToPoint | LinkDown
+)
+
+var (
+	linkNames = []struct {
+		link Links
+		name string
+	}{
+		{LinkLoopback, "loopback"},
+		{LinkPointToPoint, "point_to_point"},
+		{LinkDown, "down"},
+	}
+)
+
+// LinkNames are the names of all of the kinds of links.
+func LinkNames() []string {
+	names := make([]string, 0, len(linkNames))
+	for _, l := range linkNames {
+		names = append(names, l.name)
+	}
+	return names
+}
+
+// ParseLinks returns the kinds of links named by names. The name none
+// excludes every other kind.
+func ParseLinks(names []string) (Links, error) {
+	var links Links
+	for _, n := range names {
+		if n == "none" {
+			if len(names) != 1 {
+				return 0, fmt.Errorf("link none with other links")
+			}
+			return LinksNone, nil
+		}
+
+		found := false
+		for _, l := range linkNames {
+			if n == l.name {
+				links |= l.link
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid link %#v", n)
+		}
+	}
+	return links, nil
+}
+
+// Match returns whether the network interface ifi is one of the links.
+func (l Links) Match(ifi net.Interface) bool {
+	return l&LinkLoopback != 0 && ifi.Flags&net.FlagLoopback != 0 ||
+		l&LinkPointToPoint != 0 && ifi.Flags&net.FlagPointToPoint != 0 ||
+		l&LinkDown != 0 && ifi.Flags&net.FlagUp == 0
+}
+
+// IsPattern returns whether the interface name is a glob pattern or a
+// regular expression rather than the name of a network interface.
+func IsPattern(name string) bool {
+	return isRegexp(name) || strings.ContainsAny(name, `*?[\`)
+}
+
+// CompilePattern checks that the interface name is a valid pattern.
+func CompilePattern(name string) error {
+	_, err := compilePattern(name)
+	return err
+}
+
+// HasPatterns returns whether any of the interface names are patterns.
+func (ifs Interfaces) HasPatterns() bool {
+	for _, i := range ifs {
+		if IsPattern(i.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the settings of each of the network interfaces all
+// matched by the interfaces, keyed by name. Each network interface is
+// probed with the first of the interfaces matching it. Interfaces which
+// are not patterns always match, even when they are not in all.
+func (ifs Interfaces) Resolve(all []net.Interface) map[string]Interface {
+	resolved := make(map[string]Interface, len(ifs))
+	for _, i := range ifs {
+		if !IsPattern(i.Name) {
+			if _, ok := resolved[i.Name]; !ok {
+				resolved[i.Name] = i
+			}
+			continue
+		}
+
+		match, err := compilePattern(i.Name)
+		if err != nil {
+			continue
+		}
+		for _, ifi := range all {
+			if _, ok := resolved[ifi.Name]; ok || !match(ifi.Name) || i.Exclude.Match(ifi) {
+				continue
+			}
+			resolved[ifi.Name] = i
+		}
+	}
+	return resolved
+}
+
+// key identifies the interfaces by their names.
+func (ifs Interfaces) key() string {
+	names := make([]string, 0, len(ifs))
+	for _, i := range ifs {
+		names = append(names, i.Name)
+	}
+	return strings.Join(names, "\x00")
+}
+
+func isRegexp(name string) bool {
+	return len(name) > 1 && strings.HasPrefix(name, "/") && strings.HasSuffix(name, "/")
+}
+
+func compilePattern(name string) (func(string) bool, error) {
+	if isRegexp(name) {
+		re, err := regexp.Compile(name[1 : len(name)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	if _, err := filepath.Match(name, ""); err != nil {
+		return nil, fmt.Errorf("%w %#v", err, name)
+	}
+	return func(n string) bool {
+		ok, _ := filepath.Match(name, n)
+		return ok
+	}, nil
+}
@@ -0,0 +1,156 @@
+package neighbors
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLinks(t *testing.T) {
+	cases := []struct {
+		name  string
+		names []string
+		links Links
+		err   string
+	}{
+		{name: "empty", links: LinksNone},
+		{name: "one", names: []string{"loopback"}, links: LinkLoopback},
+		{name: "all", names: []string{"loopback", "point_to_point", "down"}, links: LinksDefault},
+		{name: "none", names: []string{"none"}, links: LinksNone},
+		{name: "none with others", names: []string{"none", "down"}, err: "link none with other links"},
+		{name: "invalid", names: []string{"bridge"}, err: `invalid link "bridge"`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert := assert.New(t)
+
+			links, err := ParseLinks(tc.names)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else {
+				assert.NoError(err)
+				assert.Equal(tc.links, links)
+			}
+		})
+	}
+}
+
+func TestLinks_Match(t *testing.T) {
+	cases := []struct {
+		name  string
+		links Links
+		flags net.Flags
+		match bool
+	}{
+		{name: "up", links: LinksDefault, flags: net.FlagUp},
+		{name: "down", links: LinksDefault, match: true},
+		{name: "loopback", links: LinksDefault, flags: net.FlagUp | net.FlagLoopback, match: true},
+		{name: "point to point", links: LinksDefault, flags: net.FlagUp | net.FlagPointToPoint, match: true},
+		{name: "loopback not excluded", links: LinkDown, flags: net.FlagUp | net.FlagLoopback},
+		{name: "none", links: LinksNone},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.match, tc.links.Match(net.Interface{Flags: tc.flags}))
+		})
+	}
+}
+
+func TestIsPattern(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern bool
+	}{
+		{name: "eth0"},
+		{name: "eth*", pattern: true},
+		{name: "wlan?", pattern: true},
+		{name: "eth[0-9]", pattern: true},
+		{name: "/^eth[0-9]+$/", pattern: true},
+		{name: "/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.pattern, IsPattern(tc.name))
+		})
+	}
+}
+
+func TestCompilePattern(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(CompilePattern("eth*"))
+	assert.NoError(CompilePattern("/^eth[0-9]+$/"))
+	assert.EqualError(CompilePattern("eth["), `syntax error in pattern "eth["`)
+	assert.EqualError(CompilePattern("/eth(/"), "error parsing regexp: missing closing ): `eth(`")
+}
+
+func TestInterfaces_Resolve(t *testing.T) {
+	all := []net.Interface{
+		{Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Name: "eth0", Flags: net.FlagUp},
+		{Name: "eth1"},
+		{Name: "eth2", Flags: net.FlagUp},
+		{Name: "tun0", Flags: net.FlagUp | net.FlagPointToPoint},
+		{Name: "wlan0", Flags: net.FlagUp},
+	}
+
+	cases := []struct {
+		name     string
+		ifs      Interfaces
+		resolved map[string]Interface
+	}{
+		{
+			name:     "names",
+			ifs:      Interfaces{{Name: "eth1"}, {Name: "usb0"}},
+			resolved: map[string]Interface{"eth1": {Name: "eth1"}, "usb0": {Name: "usb0"}},
+		},
+		{
+			name: "all",
+			ifs:  Interfaces{{Name: "*", Exclude: LinksDefault}},
+			resolved: map[string]Interface{
+				"eth0":  {Name: "*", Exclude: LinksDefault},
+				"eth2":  {Name: "*", Exclude: LinksDefault},
+				"wlan0": {Name: "*", Exclude: LinksDefault},
+			},
+		},
+		{
+			name: "all without exclusions",
+			ifs:  Interfaces{{Name: "*"}},
+			resolved: map[string]Interface{
+				"lo":    {Name: "*"},
+				"eth0":  {Name: "*"},
+				"eth1":  {Name: "*"},
+				"eth2":  {Name: "*"},
+				"tun0":  {Name: "*"},
+				"wlan0": {Name: "*"},
+			},
+		},
+		{
+			name: "first match",
+			ifs: Interfaces{
+				{Name: "eth2", Probe: ProbeNone},
+				{Name: "/^eth[0-9]+$/", Exclude: LinksDefault, Probe: ProbePing},
+				{Name: "*", Exclude: LinksDefault},
+			},
+			resolved: map[string]Interface{
+				"eth0":  {Name: "/^eth[0-9]+$/", Exclude: LinksDefault, Probe: ProbePing},
+				"eth2":  {Name: "eth2", Probe: ProbeNone},
+				"wlan0": {Name: "*", Exclude: LinksDefault},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.resolved, tc.ifs.Resolve(all))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package neighbors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+
+	"goa.design/clue/log"
+)
+
+type (
+	Pinger interface {
+		Ping(ctx context.Context, ifi, ip string, count uint) (bool, error)
+	}
+
+	pinger struct {
+		pingCmd string
+	}
+)
+
+func NewPinger() (Pinger, error) {
+	pingCmd, err := exec.LookPath("ping")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pinger{
+		pingCmd: pingCmd,
+	}, nil
+}
+
+func (p *pinger) Ping(ctx context.Context, ifi, ip string, count uint) (ok bool, err error) {
+	cmd := exec.CommandContext(ctx, p.pingCmd, pingArgs(ifi, ip, fmt.Sprint(count))...)
+	log.Debug(ctx, log.KV{K: "cmd", V: cmd})
+	err = cmd.Run()
+	if err == nil {
+		ok = true
+	} else {
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && len(exitError.Stderr) == 0 {
+			err = nil
+		} else {
+			return
+		}
+	}
+	log.Debug(ctx, log.KV{K: "cmd", V: cmd}, log.KV{K: "ok", V: ok})
+
+	return
+}
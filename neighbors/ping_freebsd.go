@@ -0,0 +1,7 @@
+package neighbors
+
+// FreeBSD ping cannot be bound to an interface, so the route to the neighbor
+// determines it.
+func pingArgs(_, ip, count string) []string {
+	return []string{"-c", count, "-q", ip}
+}
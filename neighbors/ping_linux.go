@@ -0,0 +1,5 @@
+package neighbors
+
+func pingArgs(ifi, ip, count string) []string {
+	return []string{"-c", count, "-I", ifi, "-q", ip}
+}
@@ -1,20 +1,46 @@
 package neighbors
 
+import (
+	"time"
+
+	"douglasthrift.net/presence/wrap"
+)
+
 type (
 	State interface {
 		Present() bool
 		Changed() bool
+		// Since returns when the state was first set or last changed. It is
+		// zero until the state is set.
+		Since() time.Time
+		// Interface returns the interface the device was last seen on.
+		Interface() string
+		Device() Device
 		Set(present bool)
+		// SeenOn records that the device was seen on the interface ifi.
+		SeenOn(ifi string)
+		SetDevice(device Device)
 		Reset()
 	}
 
+	// Device describes a tracked device so that it can be referred to by
+	// more than its MAC address.
+	Device struct {
+		Name, Owner, Type, Icon string
+		Tags                    []string
+	}
+
 	state struct {
 		present, was, initial bool
+		since                 time.Time
+		ifi                   string
+		device                Device
+		clock                 wrap.Clock
 	}
 )
 
-func NewState() State {
-	return &state{initial: true}
+func NewState(clock wrap.Clock) State {
+	return &state{initial: true, clock: clock}
 }
 
 func (s *state) Present() bool {
@@ -25,6 +51,18 @@ func (s *state) Changed() bool {
 	return s.present != s.was
 }
 
+func (s *state) Since() time.Time {
+	return s.since
+}
+
+func (s *state) Interface() string {
+	return s.ifi
+}
+
+func (s *state) Device() Device {
+	return s.device
+}
+
 func (s *state) Set(present bool) {
 	if s.initial {
 		s.was = !present
@@ -34,8 +72,27 @@ func (s *state) Set(present bool) {
 		s.was = s.present
 		s.present = present
 	}
+	if s.Changed() {
+		s.since = s.clock.Now()
+	}
+}
+
+func (s *state) SeenOn(ifi string) {
+	s.ifi = ifi
+}
+
+func (s *state) SetDevice(device Device) {
+	s.device = device
 }
 
 func (s *state) Reset() {
 	s.initial = true
 }
+
+// Label returns the device name, or the MAC address hw when it has none.
+func (d Device) Label(hw string) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return hw
+}
@@ -2,13 +2,17 @@ package neighbors
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/wrap"
 )
 
 func TestNewState(t *testing.T) {
-	s := NewState()
-	assert.Equal(t, &state{present: false, was: false, initial: true}, s)
+	clock := wrap.NewClock()
+	s := NewState(clock)
+	assert.Equal(t, &state{present: false, was: false, initial: true, clock: clock}, s)
 }
 
 func TestState_Present(t *testing.T) {
@@ -79,6 +83,12 @@ func TestState_Changed(t *testing.T) {
 }
 
 func TestState_Set(t *testing.T) {
+	var (
+		clock = wrap.NewFakeClock(time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC))
+		then  = clock.Now().Add(-time.Hour)
+		now   = clock.Now()
+	)
+
 	cases := []struct {
 		name   string
 		s, exp State
@@ -86,39 +96,39 @@ func TestState_Set(t *testing.T) {
 	}{
 		{
 			name: "initial to true",
-			s:    &state{initial: true},
+			s:    &state{initial: true, clock: clock},
 			p:    true,
-			exp:  &state{present: true, was: false, initial: false},
+			exp:  &state{present: true, was: false, initial: false, since: now, clock: clock},
 		},
 		{
 			name: "initial to false",
-			s:    &state{initial: true},
+			s:    &state{initial: true, clock: clock},
 			p:    false,
-			exp:  &state{present: false, was: true, initial: false},
+			exp:  &state{present: false, was: true, initial: false, since: now, clock: clock},
 		},
 		{
 			name: "true to true",
-			s:    &state{present: true},
+			s:    &state{present: true, since: then, clock: clock},
 			p:    true,
-			exp:  &state{present: true, was: true},
+			exp:  &state{present: true, was: true, since: then, clock: clock},
 		},
 		{
 			name: "true to false",
-			s:    &state{present: true},
+			s:    &state{present: true, since: then, clock: clock},
 			p:    false,
-			exp:  &state{present: false, was: true},
+			exp:  &state{present: false, was: true, since: now, clock: clock},
 		},
 		{
 			name: "false to true",
-			s:    &state{present: false, was: true},
+			s:    &state{present: false, was: true, since: then, clock: clock},
 			p:    true,
-			exp:  &state{present: true, was: false},
+			exp:  &state{present: true, was: false, since: now, clock: clock},
 		},
 		{
 			name: "false to false",
-			s:    &state{present: false, was: true},
+			s:    &state{present: false, was: true, since: then, clock: clock},
 			p:    false,
-			exp:  &state{present: false, was: false},
+			exp:  &state{present: false, was: false, since: then, clock: clock},
 		},
 	}
 
@@ -134,8 +144,52 @@ func TestState_Set(t *testing.T) {
 	}
 }
 
+func TestState_Since(t *testing.T) {
+	clock := wrap.NewFakeClock(time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC))
+	s := NewState(clock)
+	assert.True(t, s.Since().IsZero())
+
+	s.Set(true)
+	assert.Equal(t, clock.Now(), s.Since())
+
+	start := clock.Now()
+	clock.Advance(time.Minute)
+	s.Set(true)
+	assert.Equal(t, start, s.Since())
+
+	s.Set(false)
+	assert.Equal(t, clock.Now(), s.Since())
+}
+
 func TestState_Reset(t *testing.T) {
 	s := &state{initial: false}
 	s.Reset()
 	assert.Equal(t, &state{initial: true}, s)
 }
+
+func TestState_Interface(t *testing.T) {
+	s := &state{ifi: "eth0"}
+	assert.Equal(t, "eth0", s.Interface())
+}
+
+func TestState_SeenOn(t *testing.T) {
+	s := &state{ifi: "eth0"}
+	s.SeenOn("eth1")
+	assert.Equal(t, &state{ifi: "eth1"}, s)
+}
+
+func TestState_Device(t *testing.T) {
+	s := &state{device: Device{Name: "Pixel"}}
+	assert.Equal(t, Device{Name: "Pixel"}, s.Device())
+}
+
+func TestState_SetDevice(t *testing.T) {
+	s := &state{device: Device{Name: "Pixel"}}
+	s.SetDevice(Device{Name: "iPhone", Owner: "Alice", Tags: []string{"phone"}})
+	assert.Equal(t, &state{device: Device{Name: "iPhone", Owner: "Alice", Tags: []string{"phone"}}}, s)
+}
+
+func TestDevice_Label(t *testing.T) {
+	assert.Equal(t, "Alice's Pixel", Device{Name: "Alice's Pixel"}.Label("00:00:00:00:00:01"))
+	assert.Equal(t, "00:00:00:00:00:01", Device{Owner: "Alice"}.Label("00:00:00:00:00:01"))
+}
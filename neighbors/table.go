@@ -0,0 +1,38 @@
+package neighbors
+
+import (
+	"context"
+	"sync"
+)
+
+type (
+	// table is a dump of the neighbor table shared by the calls of
+	// [ARP.Present] with the same context.
+	table struct {
+		once    sync.Once
+		entries []arpEntry
+		err     error
+	}
+
+	tableKey struct{}
+)
+
+// WithSharedTable returns a context in which the neighbor table is only
+// dumped by the first call of [ARP.Present] and reused by later ones (e.g.
+// those detecting each zone in the same detection).
+func WithSharedTable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tableKey{}, &table{})
+}
+
+// table returns the entries of the neighbor table on the interfaces, or of
+// the whole table dumped once when ctx is from [WithSharedTable].
+func (a *arp) table(ctx context.Context, ifs map[string]Interface) ([]arpEntry, error) {
+	t, ok := ctx.Value(tableKey{}).(*table)
+	if !ok {
+		return a.entries(ctx, ifs)
+	}
+	t.once.Do(func() {
+		t.entries, t.err = a.entries(ctx, nil)
+	})
+	return t.entries, t.err
+}
@@ -0,0 +1,264 @@
+package presence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/neighbors"
+)
+
+type (
+	// StateOverride pins the presence of a device, or of all of the devices
+	// of a person, regardless of whether it is detected (e.g. a phone left
+	// at home while its owner is on vacation).
+	StateOverride struct {
+		// Target is the MAC address or name of a device, or the owner of
+		// devices, compared case insensitively.
+		Target  string `json:"target"`
+		Present bool   `json:"present"`
+		// Until is when the override expires. It is zero when it does not.
+		Until time.Time `json:"until,omitzero"`
+		// Reason is why the state was overridden (e.g. "vacation").
+		Reason  string    `json:"reason,omitempty"`
+		Created time.Time `json:"created"`
+	}
+
+	// stateOverrides are the overrides of a detector, which can be changed
+	// while it is detecting. They are saved to file, when there is one, on
+	// every change.
+	stateOverrides struct {
+		mu sync.Mutex
+		// file is where the overrides are saved and loaded is whether
+		// they have been read from it since it was set.
+		file      string
+		loaded    bool
+		overrides []StateOverride
+	}
+
+	// overriddenState is the state of a device whose detected presence is
+	// replaced by an override.
+	overriddenState struct {
+		neighbors.State
+		present, detected bool
+	}
+)
+
+// ErrNoOverrideTarget is returned when overriding a target which is not a
+// device or the owner of one.
+var ErrNoOverrideTarget = errors.New("no device or owner")
+
+// Expired returns whether the override has expired at now.
+func (o StateOverride) Expired(now time.Time) bool {
+	return !o.Until.IsZero() && !now.Before(o.Until)
+}
+
+// device returns whether the override targets the device by its MAC address
+// or name.
+func (o StateOverride) device(hw string, device neighbors.Device) bool {
+	return strings.EqualFold(o.Target, hw) || device.Name != "" && strings.EqualFold(o.Target, device.Name)
+}
+
+// owner returns whether the override targets the owner of the device.
+func (o StateOverride) owner(device neighbors.Device) bool {
+	return device.Owner != "" && strings.EqualFold(o.Target, device.Owner)
+}
+
+// OverrideTarget returns the target normalized (e.g. the MAC address of a
+// device in canonical form) when it is a device or the owner of one in the
+// config or any of its zones.
+func (c *Config) OverrideTarget(target string) (string, error) {
+	if hw, err := net.ParseMAC(target); err == nil {
+		target = hw.String()
+	}
+	devices := slices.Clone(c.MACAddresses)
+	for _, z := range c.Zones {
+		devices = append(devices, z.MACAddresses...)
+	}
+	for _, d := range devices {
+		o, device := StateOverride{Target: target}, d.Neighbors()
+		if o.device(d.MACAddress, device) || o.owner(device) {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("%w %#v", ErrNoOverrideTarget, target)
+}
+
+// setFile switches the overrides to the file, from which they are loaded
+// before they are next used.
+func (s *stateOverrides) setFile(file string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if file != s.file {
+		s.file, s.loaded = file, false
+	}
+}
+
+// load reads the overrides from the file unless they already have been or
+// it does not exist yet, in which case the overrides so far are kept. It
+// must be called with mu held.
+func (s *stateOverrides) load() error {
+	if s.loaded || s.file == "" {
+		s.loaded = true
+		return nil
+	}
+
+	b, err := os.ReadFile(s.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.loaded = true
+		return nil
+	}
+	var overrides []StateOverride
+	if err == nil {
+		err = json.Unmarshal(b, &overrides)
+	}
+	if err != nil {
+		return fmt.Errorf("loading overrides: %w", err)
+	}
+	s.overrides, s.loaded = overrides, true
+	return nil
+}
+
+// set replaces any override of the same target with the override.
+func (s *stateOverrides) set(o StateOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	overrides := slices.DeleteFunc(slices.Clone(s.overrides), func(old StateOverride) bool {
+		return strings.EqualFold(old.Target, o.Target)
+	})
+	return s.save(append(overrides, o))
+}
+
+// clear removes the override of the target, returning whether there was
+// one.
+func (s *stateOverrides) clear(target string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	overrides := slices.DeleteFunc(slices.Clone(s.overrides), func(o StateOverride) bool {
+		return strings.EqualFold(o.Target, target)
+	})
+	if len(overrides) == len(s.overrides) {
+		return false, nil
+	}
+	return true, s.save(overrides)
+}
+
+// list returns the overrides which have not expired at now.
+func (s *stateOverrides) list(now time.Time) ([]StateOverride, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(slices.Clone(s.overrides), func(o StateOverride) bool {
+		return o.Expired(now)
+	}), nil
+}
+
+// active returns the overrides which have not expired at now, removing the
+// ones which have. Failing to load or save them is logged rather than
+// returned so that it does not keep presence from being detected.
+func (s *stateOverrides) active(ctx context.Context, now time.Time) []StateOverride {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error loading overrides"}, log.KV{K: "file", V: s.file})
+	}
+	overrides := slices.DeleteFunc(slices.Clone(s.overrides), func(o StateOverride) bool {
+		if o.Expired(now) {
+			log.Print(ctx, log.KV{K: "msg", V: "override expired"}, log.KV{K: "target", V: o.Target}, log.KV{K: "present", V: o.Present})
+			return true
+		}
+		return false
+	})
+	if len(overrides) != len(s.overrides) {
+		if err := s.save(overrides); err != nil {
+			log.Error(ctx, err, log.KV{K: "msg", V: "error saving overrides"}, log.KV{K: "file", V: s.file})
+		}
+	}
+	return overrides
+}
+
+// save replaces the overrides, writing them to the file first when there is
+// one so that they are only changed once they have been saved.
+func (s *stateOverrides) save(overrides []StateOverride) error {
+	if s.file != "" {
+		if err := writeOverrides(s.file, overrides); err != nil {
+			return fmt.Errorf("saving overrides: %w", err)
+		}
+	}
+	s.overrides = overrides
+	return nil
+}
+
+// writeOverrides atomically replaces the file with the overrides.
+func writeOverrides(file string, overrides []StateOverride) error {
+	if overrides == nil {
+		overrides = []StateOverride{}
+	}
+	b, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// overrideFor returns the override of the device, preferring one of the
+// device itself over one of its owner.
+func overrideFor(overrides []StateOverride, hw string, device neighbors.Device) (StateOverride, bool) {
+	for _, o := range overrides {
+		if o.device(hw, device) {
+			return o, true
+		}
+	}
+	for _, o := range overrides {
+		if o.owner(device) {
+			return o, true
+		}
+	}
+	return StateOverride{}, false
+}
+
+func (s *overriddenState) Set(detected bool) {
+	s.detected = detected
+	s.State.Set(s.present)
+}
@@ -0,0 +1,109 @@
+package presence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_OverrideTarget(t *testing.T) {
+	config := &Config{
+		MACAddresses: []Device{{MACAddress: "00:00:00:00:00:0a", Name: "Alice's Pixel", Owner: "Alice"}, {MACAddress: "00:00:00:00:00:0b"}},
+		Zones:        []Zone{{Name: "rental", MACAddresses: []Device{{MACAddress: "00:00:00:00:00:0c", Owner: "Carol"}}}},
+	}
+
+	cases := []struct {
+		name, target, normalized, err string
+	}{
+		{
+			name:       "MAC address",
+			target:     "00-00-00-00-00-0A",
+			normalized: "00:00:00:00:00:0a",
+		},
+		{
+			name:       "name",
+			target:     "alice's pixel",
+			normalized: "alice's pixel",
+		},
+		{
+			name:       "owner",
+			target:     "ALICE",
+			normalized: "ALICE",
+		},
+		{
+			name:       "zone owner",
+			target:     "carol",
+			normalized: "carol",
+		},
+		{
+			name:   "unknown",
+			target: "Bob",
+			err:    `no device or owner "Bob"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := config.OverrideTarget(tc.target)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.ErrorIs(t, err, ErrNoOverrideTarget)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.normalized, target)
+			}
+		})
+	}
+}
+
+func TestStateOverride_Expired(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(StateOverride{}.Expired(start))
+	assert.False(StateOverride{Until: start.Add(time.Second)}.Expired(start))
+	assert.True(StateOverride{Until: start}.Expired(start))
+}
+
+func TestStateOverrides(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		dir   = t.TempDir()
+		file  = filepath.Join(dir, "overrides", "overrides.json")
+		alice = StateOverride{Target: "Alice", Until: start.Add(time.Hour), Created: start}
+		s     stateOverrides
+	)
+
+	// Overrides set before there is a file are kept and saved to it.
+	assert.NoError(s.set(alice))
+	s.setFile(file)
+	overrides, err := s.list(start)
+	assert.NoError(err)
+	assert.Equal([]StateOverride{alice}, overrides)
+	assert.NoFileExists(file)
+
+	bob := StateOverride{Target: "Bob", Present: true, Created: start}
+	assert.NoError(s.set(bob))
+	alice.Present = true
+	assert.NoError(s.set(alice))
+	var loaded stateOverrides
+	loaded.setFile(file)
+	overrides, err = loaded.list(start)
+	assert.NoError(err)
+	assert.Equal([]StateOverride{bob, alice}, overrides)
+
+	overrides, err = loaded.list(alice.Until)
+	assert.NoError(err)
+	assert.Equal([]StateOverride{bob}, overrides)
+
+	assert.NoError(os.WriteFile(file, []byte("{"), 0o644))
+	var corrupt stateOverrides
+	corrupt.setFile(file)
+	_, err = corrupt.list(start)
+	assert.ErrorContains(err, "loading overrides: ")
+	assert.ErrorContains(corrupt.set(bob), "loading overrides: ")
+
+}
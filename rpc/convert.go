@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"douglasthrift.net/presence"
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+)
+
+func stateProto(s *presence.Status) *presencev1.State {
+	state := &presencev1.State{
+		Present:   s.Present,
+		Since:     timestamp(s.Since),
+		Flapping:  s.Flapping,
+		GuestMode: s.GuestMode,
+		Devices:   make([]*presencev1.Device, 0, len(s.Devices)),
+		Zone:      s.Zone,
+	}
+	if len(s.Suppressed) != 0 {
+		state.Suppressed = make(map[string]uint32, len(s.Suppressed))
+		for event, n := range s.Suppressed {
+			state.Suppressed[event] = uint32(n)
+		}
+	}
+	for _, d := range s.Devices {
+		state.Devices = append(state.Devices, deviceProto(d))
+	}
+	for _, z := range s.Zones {
+		state.Zones = append(state.Zones, stateProto(z))
+	}
+	return state
+}
+
+func deviceProto(d presence.DeviceStatus) *presencev1.Device {
+	device := &presencev1.Device{
+		MacAddress: d.MACAddress,
+		Name:       d.Name,
+		Owner:      d.Owner,
+		Type:       d.Type,
+		Icon:       d.Icon,
+		Tags:       d.Tags,
+		Role:       d.Role,
+		Present:    d.Present,
+		Interface:  d.Interface,
+		Since:      timestamp(d.Since),
+		LastSeen:   timestamp(d.LastSeen),
+		Flapping:   d.Flapping,
+	}
+	if d.Override != nil {
+		device.Override = overrideProto(*d.Override)
+	}
+	return device
+}
+
+func overrideProto(o presence.StateOverride) *presencev1.Override {
+	return &presencev1.Override{
+		Target:  o.Target,
+		Present: o.Present,
+		Until:   timestamp(o.Until),
+		Reason:  o.Reason,
+		Created: timestamp(o.Created),
+	}
+}
+
+func changeProto(c presence.Change) *presencev1.Change {
+	return &presencev1.Change{
+		Time:       timestamp(c.Time),
+		MacAddress: c.MACAddress,
+		Name:       c.Name,
+		Owner:      c.Owner,
+		Interface:  c.Interface,
+		Was:        c.Was,
+		Present:    c.Present,
+		Source:     c.Source,
+		Zone:       c.Zone,
+	}
+}
+
+func reloadProto(r *presence.ReloadResult) *presencev1.ReloadConfigResponse {
+	resp := &presencev1.ReloadConfigResponse{
+		Time:  timestamp(r.Time),
+		Error: r.Error,
+	}
+	for _, c := range r.Changes {
+		resp.Changes = append(resp.Changes, &presencev1.ConfigChange{Field: c.Field, Old: jsonString(c.Old), New: jsonString(c.New)})
+	}
+	return resp
+}
+
+// jsonString returns the value encoded as JSON, or its string encoded as
+// JSON when it cannot be.
+func jsonString(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return string(b)
+}
+
+// timestamp returns the time as a timestamp or nil when it is zero.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,182 @@
+// Package rpc serves the presence control API over gRPC.
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"douglasthrift.net/presence"
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+)
+
+type (
+	// Server implements the presence control API for a runner.
+	Server struct {
+		presencev1.UnimplementedPresenceServiceServer
+		runner *presence.Runner
+		reload Reloader
+	}
+
+	// Reloader reloads the config of the runner for the reason (e.g.
+	// "rpc"), returning the result.
+	Reloader func(ctx context.Context, reason string) *presence.ReloadResult
+)
+
+// NewServer returns a server for the runner. ReloadConfig is unimplemented
+// when reload is nil.
+func NewServer(runner *presence.Runner, reload Reloader) *Server {
+	return &Server{
+		runner: runner,
+		reload: reload,
+	}
+}
+
+// NewGRPCServer returns a gRPC server with the server and reflection
+// registered, which uses TLS when tlsConfig is not nil.
+func NewGRPCServer(s *Server, tlsConfig *tls.Config) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	g := grpc.NewServer(opts...)
+	presencev1.RegisterPresenceServiceServer(g, s)
+	reflection.Register(g)
+	return g
+}
+
+func (s *Server) GetState(ctx context.Context, req *presencev1.GetStateRequest) (*presencev1.GetStateResponse, error) {
+	return &presencev1.GetStateResponse{State: stateProto(s.runner.Detector().Status())}, nil
+}
+
+func (s *Server) WatchState(req *presencev1.WatchStateRequest, stream grpc.ServerStreamingServer[presencev1.WatchStateResponse]) error {
+	ctx := stream.Context()
+	changes := s.runner.Detector().Subscribe(ctx)
+
+	err := stream.Send(&presencev1.WatchStateResponse{
+		Event: &presencev1.WatchStateResponse_State{State: stateProto(s.runner.Detector().Status())},
+	})
+	if err != nil {
+		return err
+	}
+
+	for change := range changes {
+		err = stream.Send(&presencev1.WatchStateResponse{
+			Event: &presencev1.WatchStateResponse_Change{Change: changeProto(change)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+func (s *Server) TriggerDetect(ctx context.Context, req *presencev1.TriggerDetectRequest) (*presencev1.TriggerDetectResponse, error) {
+	err := s.runner.Detect(ctx)
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return nil, status.FromContextError(err).Err()
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "detecting presence: %v", err)
+	}
+	return &presencev1.TriggerDetectResponse{State: stateProto(s.runner.Detector().Status())}, nil
+}
+
+func (s *Server) ReloadConfig(ctx context.Context, req *presencev1.ReloadConfigRequest) (*presencev1.ReloadConfigResponse, error) {
+	if s.reload == nil {
+		return nil, status.Error(codes.Unimplemented, "reloading config is not supported")
+	}
+	return reloadProto(s.reload(ctx, "rpc")), nil
+}
+
+func (s *Server) ListDevices(ctx context.Context, req *presencev1.ListDevicesRequest) (*presencev1.ListDevicesResponse, error) {
+	state := s.runner.Detector().Status()
+	if req.Zone != "" {
+		i := slices.IndexFunc(state.Zones, func(z *presence.Status) bool { return z.Zone == req.Zone })
+		if i < 0 {
+			return nil, status.Errorf(codes.NotFound, "no zone %#v", req.Zone)
+		}
+		state = state.Zones[i]
+	}
+
+	resp := &presencev1.ListDevicesResponse{Devices: make([]*presencev1.Device, 0, len(state.Devices))}
+	for _, d := range state.Devices {
+		if req.Owner != "" && d.Owner != req.Owner {
+			continue
+		}
+		resp.Devices = append(resp.Devices, deviceProto(d))
+	}
+	return resp, nil
+}
+
+func (s *Server) SetOverride(ctx context.Context, req *presencev1.SetOverrideRequest) (*presencev1.SetOverrideResponse, error) {
+	if req.Target == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing target")
+	}
+	o := presence.StateOverride{Target: req.Target, Present: req.Present, Reason: req.Reason}
+	if req.Duration != nil {
+		if err := req.Duration.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid duration: %v", err)
+		}
+		d := req.Duration.AsDuration()
+		if d <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "non-positive duration (%v)", d)
+		}
+		o.Until = s.runner.Clock().Now().Add(d)
+	}
+
+	o, err := s.runner.SetOverride(ctx, o)
+	if err != nil {
+		return nil, overrideError(err)
+	}
+	return &presencev1.SetOverrideResponse{Override: overrideProto(o)}, nil
+}
+
+func (s *Server) ClearOverride(ctx context.Context, req *presencev1.ClearOverrideRequest) (*presencev1.ClearOverrideResponse, error) {
+	cleared, err := s.runner.ClearOverride(ctx, req.Target)
+	switch {
+	case err != nil:
+		return nil, overrideError(err)
+	case !cleared:
+		return nil, status.Errorf(codes.NotFound, "no override of %#v", req.Target)
+	}
+	return &presencev1.ClearOverrideResponse{}, nil
+}
+
+func (s *Server) ListOverrides(ctx context.Context, req *presencev1.ListOverridesRequest) (*presencev1.ListOverridesResponse, error) {
+	overrides, err := s.runner.Detector().Overrides()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "listing overrides: %v", err)
+	}
+	resp := &presencev1.ListOverridesResponse{Overrides: make([]*presencev1.Override, 0, len(overrides))}
+	for _, o := range overrides {
+		resp.Overrides = append(resp.Overrides, overrideProto(o))
+	}
+	return resp, nil
+}
+
+func (s *Server) SetGuestMode(ctx context.Context, req *presencev1.SetGuestModeRequest) (*presencev1.SetGuestModeResponse, error) {
+	if err := s.runner.SetGuestMode(ctx, req.Enabled); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return &presencev1.SetGuestModeResponse{State: stateProto(s.runner.Detector().Status())}, nil
+}
+
+// overrideError returns the status of an error overriding a state.
+func overrideError(err error) error {
+	switch {
+	case errors.Is(err, presence.ErrNoOverrideTarget):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Errorf(codes.Internal, "overriding state: %v", err)
+}
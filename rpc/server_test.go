@@ -0,0 +1,400 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"douglasthrift.net/presence"
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+	"douglasthrift.net/presence/ifttt"
+	mockifttt "douglasthrift.net/presence/ifttt/mocks"
+	"douglasthrift.net/presence/neighbors"
+	mockneighbors "douglasthrift.net/presence/neighbors/mocks"
+	"douglasthrift.net/presence/wrap"
+)
+
+const (
+	alice = "00:00:00:00:00:01"
+	bob   = "00:00:00:00:00:02"
+)
+
+var start = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+
+type testRunner struct {
+	*presence.Runner
+	arp    *mockneighbors.ARP
+	client *mockifttt.Client
+}
+
+// newTestRunner runs a runner of Alice's and Bob's devices, Bob being a
+// guest, until the test ends, after detecting that only Alice is present.
+func newTestRunner(t *testing.T) *testRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &testRunner{
+		arp:    mockneighbors.NewARP(t),
+		client: mockifttt.NewClient(t),
+	}
+	r.Runner = presence.NewRunner(&presence.Runtime{
+		Config: &presence.Config{
+			Interval:     time.Minute,
+			MACAddresses: []presence.Device{{MACAddress: alice, Name: "Alice's Pixel", Owner: "Alice"}, {MACAddress: bob, Owner: "Bob", Role: "guest"}},
+		},
+		ARP:    r.arp,
+		Client: r.client,
+	}, wrap.NewFakeClock(start))
+
+	detected := make(chan struct{})
+	r.present(true, false)
+	r.client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		return "present", &ifttt.Values{}, nil
+	})
+	r.OnDetect(func(ctx context.Context, err error) {
+		select {
+		case detected <- struct{}{}:
+		default:
+		}
+	})
+
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+	<-detected
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+		assert.False(t, r.arp.HasMore(), "missing expected arp calls")
+		assert.False(t, r.client.HasMore(), "missing expected client calls")
+	})
+	return r
+}
+
+func (r *testRunner) present(alicePresent, bobPresent bool) {
+	r.arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		addrStates[alice].Set(alicePresent)
+		addrStates[bob].Set(bobPresent)
+		state.Set(alicePresent || bobPresent)
+		return nil
+	})
+}
+
+// newTestClient serves the server in process until the test ends and
+// returns a client connected to it.
+func newTestClient(t *testing.T, s *Server, serverTLS, clientTLS *tls.Config) *grpc.ClientConn {
+	var (
+		l     = bufconn.Listen(1 << 20)
+		g     = NewGRPCServer(s, serverTLS)
+		creds = insecure.NewCredentials()
+	)
+	if clientTLS != nil {
+		creds = credentials.NewTLS(clientTLS)
+	}
+
+	go func() { _ = g.Serve(l) }()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		g.Stop()
+	})
+	return conn
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		runner = newTestRunner(t)
+		client = presencev1.NewPresenceServiceClient(newTestClient(t, NewServer(runner.Runner, nil), nil, nil))
+		since  = timestamppb.New(start)
+		alice  = &presencev1.Device{MacAddress: alice, Name: "Alice's Pixel", Owner: "Alice", Role: "resident", Present: true, Since: since, LastSeen: since}
+		bob    = &presencev1.Device{MacAddress: bob, Owner: "Bob", Role: "guest", Since: since}
+	)
+
+	state, err := client.GetState(ctx, &presencev1.GetStateRequest{})
+	if assert.NoError(err) {
+		assert.True(state.State.Present)
+		assert.Equal(since.AsTime(), state.State.Since.AsTime())
+		assert.Len(state.State.Devices, 2)
+	}
+
+	devices, err := client.ListDevices(ctx, &presencev1.ListDevicesRequest{})
+	if assert.NoError(err) && assert.Len(devices.Devices, 2) {
+		assert.Equal(alice.String(), devices.Devices[0].String())
+		assert.Equal(bob.String(), devices.Devices[1].String())
+	}
+	devices, err = client.ListDevices(ctx, &presencev1.ListDevicesRequest{Owner: "Bob"})
+	if assert.NoError(err) && assert.Len(devices.Devices, 1) {
+		assert.Equal(bob.String(), devices.Devices[0].String())
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchState(watchCtx, &presencev1.WatchStateRequest{})
+	if !assert.NoError(err) {
+		return
+	}
+	event, err := stream.Recv()
+	if assert.NoError(err) {
+		assert.True(event.GetState().GetPresent())
+	}
+
+	runner.present(true, true)
+	detected, err := client.TriggerDetect(ctx, &presencev1.TriggerDetectRequest{})
+	if assert.NoError(err) && assert.Len(detected.State.Devices, 2) {
+		assert.True(detected.State.Devices[1].Present)
+	}
+
+	event, err = stream.Recv()
+	if assert.NoError(err) {
+		change := event.GetChange()
+		assert.Equal(bob.MacAddress, change.GetMacAddress())
+		assert.Equal("Bob", change.GetOwner())
+		assert.False(change.GetWas())
+		assert.True(change.GetPresent())
+		assert.Equal(presence.ChangeSourceDetection, change.GetSource())
+		assert.Equal(since.AsTime(), change.GetTime().AsTime())
+	}
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(codes.Canceled, status.Code(err))
+
+	runner.arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		return fmt.Errorf("arp failed")
+	})
+	_, err = client.TriggerDetect(ctx, &presencev1.TriggerDetectRequest{})
+	assert.Equal(codes.Internal, status.Code(err))
+	assert.ErrorContains(err, "detecting presence: arp failed")
+
+	_, err = client.ReloadConfig(ctx, &presencev1.ReloadConfigRequest{})
+	assert.Equal(codes.Unimplemented, status.Code(err))
+}
+
+func TestServer_ReloadConfig(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		runner = newTestRunner(t)
+		reload = func(ctx context.Context, reason string) *presence.ReloadResult {
+			assert.Equal("rpc", reason)
+			return &presence.ReloadResult{
+				Time:    start,
+				Reason:  reason,
+				Changes: []presence.ConfigChange{{Field: "interval", Old: "30s", New: "1m0s"}, {Field: "ping_count", Old: 1, New: 2}},
+			}
+		}
+		client = presencev1.NewPresenceServiceClient(newTestClient(t, NewServer(runner.Runner, reload), nil, nil))
+	)
+
+	resp, err := client.ReloadConfig(ctx, &presencev1.ReloadConfigRequest{})
+	if assert.NoError(err) {
+		assert.Equal(start, resp.Time.AsTime())
+		assert.Empty(resp.Error)
+		if assert.Len(resp.Changes, 2) {
+			assert.Equal([]string{"interval", `"30s"`, `"1m0s"`}, []string{resp.Changes[0].Field, resp.Changes[0].Old, resp.Changes[0].New})
+			assert.Equal([]string{"ping_count", "1", "2"}, []string{resp.Changes[1].Field, resp.Changes[1].Old, resp.Changes[1].New})
+		}
+	}
+}
+
+func TestServer_Reflection(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		runner = newTestRunner(t)
+		client = reflectionpb.NewServerReflectionClient(newTestClient(t, NewServer(runner.Runner, nil), nil, nil))
+	)
+
+	stream, err := client.ServerReflectionInfo(context.Background())
+	if !assert.NoError(err) {
+		return
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	resp, err := stream.Recv()
+	if assert.NoError(err) {
+		var services []string
+		for _, s := range resp.GetListServicesResponse().GetService() {
+			services = append(services, s.Name)
+		}
+		assert.Contains(services, presencev1.PresenceService_ServiceDesc.ServiceName)
+	}
+	assert.NoError(stream.CloseSend())
+}
+
+func TestServer_Override(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		runner = newTestRunner(t)
+		client = presencev1.NewPresenceServiceClient(newTestClient(t, NewServer(runner.Runner, nil), nil, nil))
+		until  = timestamppb.New(start.Add(7 * 24 * time.Hour))
+	)
+
+	for _, req := range []*presencev1.SetOverrideRequest{
+		{},
+		{Target: "Carol"},
+		{Target: "Alice", Duration: durationpb.New(-time.Hour)},
+	} {
+		_, err := client.SetOverride(ctx, req)
+		assert.Equal(codes.InvalidArgument, status.Code(err), "%v", err)
+	}
+
+	runner.present(true, false)
+	runner.client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.False(data.Present)
+		return "absent", &ifttt.Values{}, nil
+	})
+	set, err := client.SetOverride(ctx, &presencev1.SetOverrideRequest{Target: "alice", Duration: durationpb.New(7 * 24 * time.Hour), Reason: "vacation"})
+	override := &presencev1.Override{Target: "alice", Until: until, Reason: "vacation", Created: timestamppb.New(start)}
+	if assert.NoError(err) {
+		assert.Equal(override.String(), set.Override.String())
+	}
+
+	state, err := client.GetState(ctx, &presencev1.GetStateRequest{})
+	if assert.NoError(err) {
+		assert.False(state.State.Present)
+		assert.False(state.State.Devices[0].Present)
+		assert.Equal(override.String(), state.State.Devices[0].Override.String())
+		assert.Nil(state.State.Devices[1].Override)
+	}
+	overrides, err := client.ListOverrides(ctx, &presencev1.ListOverridesRequest{})
+	if assert.NoError(err) && assert.Len(overrides.Overrides, 1) {
+		assert.Equal(override.String(), overrides.Overrides[0].String())
+	}
+
+	runner.present(true, false)
+	runner.client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.True(data.Present)
+		return "present", &ifttt.Values{}, nil
+	})
+	_, err = client.ClearOverride(ctx, &presencev1.ClearOverrideRequest{Target: "ALICE"})
+	assert.NoError(err)
+	_, err = client.ClearOverride(ctx, &presencev1.ClearOverrideRequest{Target: "alice"})
+	assert.Equal(codes.NotFound, status.Code(err))
+
+	overrides, err = client.ListOverrides(ctx, &presencev1.ListOverridesRequest{})
+	if assert.NoError(err) {
+		assert.Empty(overrides.Overrides)
+	}
+}
+
+func TestServer_GuestMode(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	var (
+		runner = newTestRunner(t)
+		client = presencev1.NewPresenceServiceClient(newTestClient(t, NewServer(runner.Runner, nil), nil, nil))
+	)
+
+	runner.present(false, true)
+	runner.client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.False(data.Present)
+		return "absent", &ifttt.Values{}, nil
+	})
+	detected, err := client.TriggerDetect(ctx, &presencev1.TriggerDetectRequest{})
+	if assert.NoError(err) {
+		assert.False(detected.State.Present)
+		assert.False(detected.State.GuestMode)
+		assert.True(detected.State.Devices[1].Present)
+	}
+
+	runner.present(false, true)
+	runner.client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.True(data.Present)
+		return "present", &ifttt.Values{}, nil
+	})
+	resp, err := client.SetGuestMode(ctx, &presencev1.SetGuestModeRequest{Enabled: true})
+	if assert.NoError(err) {
+		assert.True(resp.State.Present)
+		assert.True(resp.State.GuestMode)
+	}
+	assert.True(runner.Detector().GuestMode())
+}
+
+func TestServer_Zones(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert := assert.New(t)
+
+	var (
+		arp          = mockneighbors.NewARP(t)
+		client       = mockifttt.NewClient(t)
+		rentalClient = mockifttt.NewClient(t)
+		runner       = presence.NewRunner(&presence.Runtime{
+			Config: &presence.Config{
+				Interval:     time.Minute,
+				MACAddresses: []presence.Device{{MACAddress: alice, Owner: "Alice"}},
+				Zones:        []presence.Zone{{Name: "rental", MACAddresses: []presence.Device{{MACAddress: bob, Owner: "Bob"}}}},
+			},
+			ARP:         arp,
+			Client:      client,
+			ZoneClients: map[string]ifttt.Client{"rental": rentalClient},
+		}, wrap.NewFakeClock(start))
+		detected = make(chan struct{}, 1)
+		done     = make(chan error)
+	)
+	for _, hw := range []string{alice, bob} {
+		arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+			addrStates[hw].Set(hw == bob)
+			state.Set(hw == bob)
+			return nil
+		})
+	}
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		return "absent", &ifttt.Values{}, nil
+	})
+	rentalClient.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.Equal("rental", data.Zone)
+		return "present", &ifttt.Values{}, nil
+	})
+	runner.OnDetect(func(ctx context.Context, err error) { detected <- struct{}{} })
+	go func() { done <- runner.Run(ctx) }()
+	<-detected
+	defer func() {
+		cancel()
+		assert.NoError(<-done)
+	}()
+
+	c := presencev1.NewPresenceServiceClient(newTestClient(t, NewServer(runner, nil), nil, nil))
+	state, err := c.GetState(ctx, &presencev1.GetStateRequest{})
+	if assert.NoError(err) && assert.Len(state.State.Zones, 1) {
+		assert.False(state.State.Present)
+		assert.Equal("rental", state.State.Zones[0].Zone)
+		assert.True(state.State.Zones[0].Present)
+	}
+
+	devices, err := c.ListDevices(ctx, &presencev1.ListDevicesRequest{Zone: "rental"})
+	if assert.NoError(err) && assert.Len(devices.Devices, 1) {
+		assert.Equal(bob, devices.Devices[0].MacAddress)
+	}
+	_, err = c.ListDevices(ctx, &presencev1.ListDevicesRequest{Zone: "garage"})
+	assert.Equal(codes.NotFound, status.Code(err))
+}
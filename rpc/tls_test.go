@@ -0,0 +1,142 @@
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	presencev1 "douglasthrift.net/presence/api/presence/v1"
+)
+
+// issue writes a certificate for the name signed by the parent (or self
+// signed when it is nil) and its key to dir, returning them and their
+// paths.
+func issue(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, certFile, keyFile
+}
+
+func TestTLSConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		dir                             = t.TempDir()
+		ca, caKey, caFile, _            = issue(t, dir, "ca", nil, nil)
+		_, _, serverCertFile, serverKey = issue(t, dir, "presence", ca, caKey)
+		_, _, clientCertFile, clientKey = issue(t, dir, "dashboard", ca, caKey)
+		_, _, otherCertFile, otherKey   = issue(t, dir, "other", nil, nil)
+	)
+
+	_, err := TLSConfig(filepath.Join(dir, "missing.crt"), serverKey, "")
+	assert.ErrorContains(err, "loading certificate: ")
+	_, err = TLSConfig(serverCertFile, serverKey, filepath.Join(dir, "missing.crt"))
+	assert.ErrorContains(err, "reading client CA: ")
+	_, err = TLSConfig(serverCertFile, serverKey, serverKey)
+	assert.EqualError(err, "no certificates in client CA "+serverKey)
+
+	serverTLS, err := TLSConfig(serverCertFile, serverKey, caFile)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(tls.RequireAndVerifyClientCert, serverTLS.ClientAuth)
+
+	_, err = ClientTLSConfig(filepath.Join(dir, "missing.crt"), "", "")
+	assert.ErrorContains(err, "reading CA: ")
+	_, err = ClientTLSConfig(serverKey, "", "")
+	assert.EqualError(err, "no certificates in CA "+serverKey)
+	_, err = ClientTLSConfig(caFile, clientCertFile, serverKey)
+	assert.ErrorContains(err, "loading certificate: ")
+
+	clientTLS := func(certFile, keyFile string) *tls.Config {
+		config, err := ClientTLSConfig(caFile, certFile, keyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.ServerName = "presence"
+		return config
+	}
+
+	cases := []struct {
+		name              string
+		certFile, keyFile string
+		code              codes.Code
+	}{
+		{
+			name:     "client certificate",
+			certFile: clientCertFile,
+			keyFile:  clientKey,
+			code:     codes.OK,
+		},
+		{
+			name: "no client certificate",
+			code: codes.Unavailable,
+		},
+		{
+			name:     "untrusted client certificate",
+			certFile: otherCertFile,
+			keyFile:  otherKey,
+			code:     codes.Unavailable,
+		},
+	}
+
+	runner := newTestRunner(t)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := newTestClient(t, NewServer(runner.Runner, nil), serverTLS, clientTLS(tc.certFile, tc.keyFile))
+			_, err := presencev1.NewPresenceServiceClient(conn).GetState(context.Background(), &presencev1.GetStateRequest{})
+			assert.Equal(tc.code, status.Code(err), "%v", err)
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// Runner runs the detection loop of a runtime, detecting presence every
+	// config interval until its context is done, so that presence detection
+	// can be embedded in other programs.
+	Runner struct {
+		detector Detector
+		clock    wrap.Clock
+		// reloaded signals the loop that the runtime was replaced and
+		// applied is the runtime the detector is using.
+		reloaded chan struct{}
+		applied  *Runtime
+		// detects are requests for the loop to detect now, which are sent
+		// the detection's error.
+		detects chan chan error
+
+		mu       sync.Mutex
+		rt       *Runtime
+		running  bool
+		onDetect []func(ctx context.Context, err error)
+		onChange []func(ctx context.Context, status *Status)
+	}
+)
+
+// ErrRunning is returned when running a runner which is already running.
+var ErrRunning = errors.New("runner already running")
+
+// NewRunner returns a runner for the runtime which uses the clock for its
+// detection times and ticker.
+func NewRunner(rt *Runtime, clock wrap.Clock) *Runner {
+	return &Runner{
+		detector: newZonedDetector(rt, clock),
+		clock:    clock,
+		reloaded: make(chan struct{}, 1),
+		applied:  rt,
+		detects:  make(chan chan error),
+		rt:       rt,
+	}
+}
+
+// Detector returns the detector run by the runner. Its status is safe to
+// read while the runner is running, but it should not be changed or used
+// for detection.
+func (r *Runner) Detector() Detector {
+	return r.detector
+}
+
+// Clock returns the clock the runner uses.
+func (r *Runner) Clock() wrap.Clock {
+	return r.clock
+}
+
+// Runtime returns the runtime the runner was last given.
+func (r *Runner) Runtime() *Runtime {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rt
+}
+
+// OnDetect registers f to be called by the loop after each detection with
+// its error, if any.
+func (r *Runner) OnDetect(f func(ctx context.Context, err error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onDetect = append(r.onDetect, f)
+}
+
+// OnChange registers f to be called by the loop with the status after each
+// detection which found the presence of the household or any device
+// changed, including the first one.
+func (r *Runner) OnChange(f func(ctx context.Context, status *Status)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onChange = append(r.onChange, f)
+}
+
+// Reload builds a runtime from the config, which should come from one of
+// the ParseConfig functions, and replaces the runtime with it. The runtime
+// in use is kept when building fails.
+func (r *Runner) Reload(config *Config) error {
+	rt, err := NewRuntimeFromConfig(config, r.clock, r.Runtime().Debug)
+	if err != nil {
+		return err
+	}
+	r.Replace(rt)
+	return nil
+}
+
+// Replace replaces the runtime, keeping the rate limits of the IFTTT events
+// which are unchanged. A running loop starts using it with an immediate
+// detection and restarts its ticker at the new interval. It is safe to call
+// from any goroutine.
+func (r *Runner) Replace(rt *Runtime) {
+	r.mu.Lock()
+	rt.keepLimits(r.rt)
+	r.rt = rt
+	r.mu.Unlock()
+
+	select {
+	case r.reloaded <- struct{}{}:
+	default:
+	}
+}
+
+// Detect makes the running loop detect presence now and returns the
+// detection's error once it is done. It waits until ctx is done when the
+// loop is not running.
+func (r *Runner) Detect(ctx context.Context) error {
+	done := make(chan error, 1)
+	select {
+	case r.detects <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetOverride overrides the state of the device or owner targeted by o,
+// normalizing its target and setting when it was created, and then detects
+// presence so that it takes effect now. It returns the override, or an
+// error wrapping ErrNoOverrideTarget when its target is not in the config.
+// Like Detect, it waits until ctx is done when the loop is not running.
+func (r *Runner) SetOverride(ctx context.Context, o StateOverride) (StateOverride, error) {
+	target, err := r.Runtime().Config.OverrideTarget(o.Target)
+	if err != nil {
+		return o, err
+	}
+	o.Target, o.Created = target, r.clock.Now()
+	if err = r.detector.SetOverride(o); err != nil {
+		return o, err
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "set override"}, log.KV{K: "target", V: o.Target}, log.KV{K: "present", V: o.Present},
+		log.KV{K: "until", V: o.Until}, log.KV{K: "reason", V: o.Reason})
+
+	return o, r.detectNow(ctx)
+}
+
+// ClearOverride removes the override of the target and then detects
+// presence so that the target's detected state is used again now. It
+// returns whether there was an override of the target.
+func (r *Runner) ClearOverride(ctx context.Context, target string) (bool, error) {
+	if hw, err := net.ParseMAC(target); err == nil {
+		target = hw.String()
+	}
+	cleared, err := r.detector.ClearOverride(target)
+	if err != nil || !cleared {
+		return cleared, err
+	}
+	log.Print(ctx, log.KV{K: "msg", V: "cleared override"}, log.KV{K: "target", V: target})
+
+	return true, r.detectNow(ctx)
+}
+
+// SetGuestMode sets whether guest devices count toward the household and
+// then detects presence so that the household follows it now.
+func (r *Runner) SetGuestMode(ctx context.Context, enabled bool) error {
+	r.detector.SetGuestMode(enabled)
+	log.Print(ctx, log.KV{K: "msg", V: "set guest mode"}, log.KV{K: "enabled", V: enabled})
+
+	return r.detectNow(ctx)
+}
+
+// detectNow detects presence after an override or guest mode was changed.
+// Only its context's error is returned since the loop handles detection
+// errors and the change has already been made regardless.
+func (r *Runner) detectNow(ctx context.Context) error {
+	if err := r.Detect(ctx); ctx.Err() != nil {
+		return err
+	}
+	return nil
+}
+
+// Run detects presence immediately and then every config interval until
+// ctx is done, finishing any detection in progress before returning nil.
+// Detections are not cancelled by ctx but each is limited to the interval.
+// Detection errors are logged and passed to the OnDetect functions rather
+// than stopping the loop.
+func (r *Runner) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return ErrRunning
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.running = false
+	}()
+
+	// Any replacement before running is applied now.
+	select {
+	case <-r.reloaded:
+	default:
+	}
+	rt := r.apply()
+
+	ticker := r.clock.NewTicker(rt.Config.Interval)
+	defer ticker.Stop()
+
+	last, _ := r.detect(ctx, nil)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Print(ctx, log.KV{K: "msg", V: "stopped detecting presence"})
+			return nil
+		case <-ticker.C():
+			last, _ = r.detect(ctx, last)
+		case done := <-r.detects:
+			var err error
+			last, err = r.detect(ctx, last)
+			done <- err
+		case <-r.reloaded:
+			rt = r.apply()
+			ticker.Reset(rt.Config.Interval)
+			last, _ = r.detect(ctx, last)
+		}
+	}
+}
+
+// apply makes the detector use the runtime it was last given and returns
+// it.
+func (r *Runner) apply() *Runtime {
+	rt := r.Runtime()
+	if rt != r.applied {
+		r.detector.Runtime(rt)
+		r.applied = rt
+	}
+	return rt
+}
+
+// detect detects presence and calls the registered functions, returning
+// the new status and the detection's error. Stopping waits for the probes
+// of the detection rather than killing them partway through.
+func (r *Runner) detect(ctx context.Context, last *Status) (*Status, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.applied.Config.Interval)
+	defer cancel()
+
+	err := r.detector.Detect(ctx)
+	if err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error detecting presence"})
+	}
+	status := r.detector.Status()
+
+	r.mu.Lock()
+	onDetect, onChange := r.onDetect, r.onChange
+	r.mu.Unlock()
+
+	if statusChanged(last, status) {
+		for _, f := range onChange {
+			f(ctx, status)
+		}
+	}
+	for _, f := range onDetect {
+		f(ctx, err)
+	}
+	return status, err
+}
+
+// statusChanged returns whether the presence of the household or any
+// device, or guest mode, differs between the statuses or those of any of
+// their zones.
+func statusChanged(old, new *Status) bool {
+	if old == nil {
+		return true
+	}
+	if old.Present != new.Present || old.GuestMode != new.GuestMode || len(old.Devices) != len(new.Devices) || len(old.Zones) != len(new.Zones) {
+		return true
+	}
+	for i, d := range new.Devices {
+		if o := old.Devices[i]; o.MACAddress != d.MACAddress || o.Present != d.Present {
+			return true
+		}
+	}
+	for i, z := range new.Zones {
+		if o := old.Zones[i]; o.Zone != z.Zone || statusChanged(o, z) {
+			return true
+		}
+	}
+	return false
+}
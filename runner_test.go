@@ -0,0 +1,200 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/ifttt"
+	mockifttt "douglasthrift.net/presence/ifttt/mocks"
+	"douglasthrift.net/presence/neighbors"
+	mockneighbors "douglasthrift.net/presence/neighbors/mocks"
+	"douglasthrift.net/presence/wrap"
+)
+
+func TestRunner(t *testing.T) {
+	ctx, cancel := context.WithCancel(log.Context(context.Background(), log.WithDebug()))
+	defer cancel()
+	assert := assert.New(t)
+
+	const mac = "00:00:00:00:00:01"
+
+	var (
+		clock  = wrap.NewFakeClock(start)
+		arp1   = mockneighbors.NewARP(t)
+		arp2   = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		rt1    = &Runtime{
+			Config: &Config{Interval: time.Minute, MACAddresses: []Device{{MACAddress: mac}}},
+			ARP:    arp1,
+			Client: client,
+		}
+		rt2 = &Runtime{
+			Config: &Config{Interval: time.Hour, MACAddresses: []Device{{MACAddress: mac}}},
+			ARP:    arp2,
+			Client: client,
+		}
+		runner   = NewRunner(rt1, clock)
+		detected = make(chan error)
+		changed  = make(chan *Status, 10)
+		present  = func(arp *mockneighbors.ARP, present bool) {
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				addrStates[mac].Set(present)
+				state.Set(present)
+				return nil
+			})
+		}
+		trigger = func() {
+			client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+				return "event", &ifttt.Values{}, nil
+			})
+		}
+		done = make(chan error)
+	)
+	runner.OnDetect(func(ctx context.Context, err error) { detected <- err })
+	runner.OnChange(func(ctx context.Context, status *Status) { changed <- status })
+
+	// The first detection happens immediately and is a change.
+	present(arp1, true)
+	trigger()
+	go func() { done <- runner.Run(ctx) }()
+	assert.NoError(<-detected)
+	if status := <-changed; assert.NotNil(status) {
+		assert.True(status.Present)
+	}
+	assert.ErrorIs(runner.Run(ctx), ErrRunning)
+
+	// Detections without changes do not call OnChange.
+	present(arp1, true)
+	clock.Advance(time.Minute)
+	assert.NoError(<-detected)
+	assert.Empty(changed)
+
+	// Detections can be made on demand.
+	present(arp1, true)
+	go func() { done <- runner.Detect(ctx) }()
+	assert.NoError(<-detected)
+	assert.NoError(<-done)
+
+	// Errors are passed on without stopping the loop.
+	arp1.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		return fmt.Errorf("arp failed")
+	})
+	clock.Advance(time.Minute)
+	assert.EqualError(<-detected, "arp failed")
+
+	// Replacing the runtime detects with it immediately and at its
+	// interval from then on.
+	present(arp2, false)
+	trigger()
+	runner.Replace(rt2)
+	assert.NoError(<-detected)
+	if status := <-changed; assert.NotNil(status) {
+		assert.False(status.Present)
+	}
+	assert.Same(rt2, runner.Runtime())
+	clock.Advance(time.Minute)
+	present(arp2, false)
+	clock.Advance(59 * time.Minute)
+	assert.NoError(<-detected)
+
+	cancel()
+	assert.NoError(<-done)
+	assert.False(arp1.HasMore(), "missing expected arp calls")
+	assert.False(arp2.HasMore(), "missing expected arp calls")
+	assert.False(client.HasMore(), "missing expected client calls")
+}
+
+func TestRunner_Reload(t *testing.T) {
+	assert := assert.New(t)
+
+	rt := &Runtime{Config: &Config{Interval: time.Minute}}
+	runner := NewRunner(rt, wrap.NewFakeClock(start))
+
+	config := &Config{PingCount: 1, IFTTT: IFTTT{BaseURL: "://", Key: "key"}}
+	assert.Error(runner.Reload(config))
+	assert.Same(rt, runner.Runtime())
+}
+
+func TestRunner_Replace(t *testing.T) {
+	ctx := context.Background()
+	assert := assert.New(t)
+
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	runtime := func(limit RateLimit) *Runtime {
+		events := Events{Present: Event{Event: defaultPresentEvent, RateLimit: limit}, Absent: Event{Event: defaultAbsentEvent}}
+		client, err := ifttt.NewClient(ts.Client(), ts.URL, "key", events.IFTTT(), wrap.NewFakeClock(start), false)
+		assert.NoError(err)
+		return &Runtime{Config: &Config{Interval: time.Minute, IFTTT: IFTTT{Events: events}}, Client: client}
+	}
+	data := &ifttt.Data{Present: true}
+
+	rt := runtime(RateLimit{Events: 1, Per: time.Hour})
+	runner := NewRunner(rt, wrap.NewFakeClock(start))
+	_, _, err := rt.Client.Trigger(ctx, data)
+	assert.NoError(err)
+
+	// Reloading does not reset the rate limits which are unchanged.
+	rt = runtime(RateLimit{Events: 1, Per: time.Hour})
+	runner.Replace(rt)
+	_, _, err = rt.Client.Trigger(ctx, data)
+	var rle *ifttt.RateLimitError
+	assert.ErrorAs(err, &rle)
+
+	rt = runtime(RateLimit{Events: 2, Per: time.Hour})
+	runner.Replace(rt)
+	_, _, err = rt.Client.Trigger(ctx, data)
+	assert.NoError(err)
+}
+
+func TestRunner_Stop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert := assert.New(t)
+
+	var (
+		arp     = mockneighbors.NewARP(t)
+		runner  = NewRunner(&Runtime{Config: &Config{Interval: time.Minute}, ARP: arp}, wrap.NewFakeClock(start))
+		probing = make(chan struct{})
+		stopped = make(chan struct{})
+		done    = make(chan error)
+	)
+	arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		close(probing)
+		<-stopped
+		assert.NoError(ctx.Err())
+		deadline, ok := ctx.Deadline()
+		assert.True(ok)
+		assert.WithinDuration(time.Now().Add(time.Minute), deadline, time.Minute)
+		return nil
+	})
+	runner.OnDetect(func(ctx context.Context, err error) { assert.NoError(err) })
+
+	go func() { done <- runner.Run(ctx) }()
+	<-probing
+	cancel()
+	select {
+	case <-done:
+		assert.Fail("stopped before the detection finished")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(stopped)
+	assert.NoError(<-done)
+	assert.False(arp.HasMore(), "missing expected arp calls")
+}
+
+func TestRunner_Detect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := NewRunner(&Runtime{Config: &Config{Interval: time.Minute}}, wrap.NewFakeClock(start))
+	assert.ErrorIs(t, runner.Detect(ctx), context.Canceled)
+}
@@ -0,0 +1,118 @@
+package presence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"douglasthrift.net/presence/history"
+	"douglasthrift.net/presence/ifttt"
+	"douglasthrift.net/presence/neighbors"
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// Runtime is a config and the dependencies built from it which are
+	// needed to detect presence.
+	Runtime struct {
+		Config *Config
+		ARP    neighbors.ARP
+		Client ifttt.Client
+		// ZoneClients are the IFTTT clients of the zones of the config by
+		// name. The zones without one use Client.
+		ZoneClients map[string]ifttt.Client
+		// History is nil when the config has no history file.
+		History history.History
+		// Debug is whether the IFTTT client logs its requests and
+		// responses.
+		Debug bool
+	}
+
+	// ReloadResult is the outcome of reloading the runtime.
+	ReloadResult struct {
+		Time time.Time `json:"time"`
+		// Reason is what caused the reload (e.g. "signal" or "watch").
+		Reason string `json:"reason"`
+		// Error is why the reload failed and the old runtime was kept. It
+		// is empty when the reload succeeded.
+		Error   string         `json:"error,omitempty"`
+		Changes []ConfigChange `json:"changes,omitempty"`
+	}
+)
+
+// NewRuntime parses the config and builds all of its dependencies without
+// modifying any existing runtime so that a failure can leave it untouched.
+func NewRuntime(ctx context.Context, name string, overrides ConfigOverrides, wNet wrap.Net, clock wrap.Clock, debug bool) (*Runtime, error) {
+	config, err := ParseConfigWithOverrides(ctx, name, overrides, wNet)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return NewRuntimeFromConfig(config, clock, debug)
+}
+
+// NewRuntimeFromConfig builds all of the dependencies of a config which has
+// already been parsed, which rate limit and prune by the clock.
+func NewRuntimeFromConfig(config *Config, clock wrap.Clock, debug bool) (*Runtime, error) {
+	arp, err := neighbors.NewARP(config.PingCount)
+	if err != nil {
+		return nil, fmt.Errorf("finding dependencies: %w", err)
+	}
+
+	client, err := ifttt.NewClient(http.DefaultClient, config.IFTTT.BaseURL, config.IFTTT.Key, config.IFTTT.Events.IFTTT(), clock, debug)
+	if err != nil {
+		return nil, fmt.Errorf("creating IFTTT client: %w", err)
+	}
+
+	rt := &Runtime{
+		Config: config,
+		ARP:    arp,
+		Client: client,
+		Debug:  debug,
+	}
+	for _, z := range config.Zones {
+		client, err := ifttt.NewClient(http.DefaultClient, config.IFTTT.BaseURL, config.IFTTT.Key, config.zoneConfig(z).IFTTT.Events.IFTTT(), clock, debug)
+		if err != nil {
+			return nil, fmt.Errorf("creating IFTTT client of zone %v: %w", z.Name, err)
+		}
+		if rt.ZoneClients == nil {
+			rt.ZoneClients = make(map[string]ifttt.Client, len(config.Zones))
+		}
+		rt.ZoneClients[z.Name] = client
+	}
+	if config.History.File != "" {
+		rt.History = history.New(config.History.File, config.History.Retention(), clock)
+	}
+	return rt, nil
+}
+
+// keepLimits makes the IFTTT clients of the runtime carry on with the rate
+// limits of those of the old runtime it replaces, so that reloading the
+// config does not reset them.
+func (rt *Runtime) keepLimits(old *Runtime) {
+	ifttt.KeepLimits(old.Client, rt.Client)
+	for name, client := range rt.ZoneClients {
+		if oc, ok := old.ZoneClients[name]; ok {
+			ifttt.KeepLimits(oc, client)
+		}
+	}
+}
+
+// Reload builds a new runtime from the config and reports how it differs
+// from the old runtime. When the reload fails, the returned runtime is the
+// old one.
+func Reload(ctx context.Context, old *Runtime, name string, overrides ConfigOverrides, wNet wrap.Net, clock wrap.Clock, debug bool, reason string) (*Runtime, *ReloadResult) {
+	result := &ReloadResult{
+		Time:   clock.Now(),
+		Reason: reason,
+	}
+
+	rt, err := NewRuntime(ctx, name, overrides, wNet, clock, debug)
+	if err != nil {
+		result.Error = err.Error()
+		return old, result
+	}
+
+	result.Changes = DiffConfig(old.Config, rt.Config)
+	return rt, result
+}
@@ -0,0 +1,31 @@
+package presence
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/wrap"
+	mockwrap "douglasthrift.net/presence/wrap/mocks"
+)
+
+func TestReload(t *testing.T) {
+	t.Run("error keeps old runtime", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var (
+			old  = &Runtime{Config: &Config{}}
+			wNet = mockwrap.NewNet(t)
+		)
+
+		rt, result := Reload(context.Background(), old, filepath.Join("tests", "negative_interval.yml"), nil, wNet, wrap.NewFakeClock(start), false, "signal")
+		assert.Same(old, rt)
+		assert.Equal("signal", result.Reason)
+		assert.Equal("parsing config: negative interval (-1ns)", result.Error)
+		assert.Empty(result.Changes)
+		assert.Equal(start, result.Time)
+		assert.False(wNet.HasMore(), "missing expected net calls")
+	})
+}
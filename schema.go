@@ -0,0 +1,644 @@
+package presence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"douglasthrift.net/presence/neighbors"
+)
+
+type (
+	// Schema is a JSON Schema.
+	Schema struct {
+		Schema               string             `json:"$schema,omitempty"`
+		Title                string             `json:"title,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		OneOf                []*Schema          `json:"oneOf,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		UniqueItems          bool               `json:"uniqueItems,omitempty"`
+		Minimum              *int               `json:"minimum,omitempty"`
+		Pattern              string             `json:"pattern,omitempty"`
+		Enum                 []string           `json:"enum,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Default              any                `json:"default,omitempty"`
+
+		// patternName describes what matches the pattern in errors.
+		patternName string
+	}
+
+	// SchemaError is a config value which does not match the schema.
+	SchemaError struct {
+		// File is the config file or fragment containing the value.
+		File         string
+		Line, Column int
+		// Field is the dotted path of the field (e.g. "ifttt.base_url").
+		Field   string
+		Message string
+	}
+
+	schemaField struct {
+		description, format  string
+		pattern, patternName string
+		enum                 []string
+		def                  any
+		required             bool
+		minItems, minimum    *int
+		unique               bool
+	}
+)
+
+const (
+	schemaVersion   = "https://json-schema.org/draft/2020-12/schema"
+	durationPattern = `^([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+	macPattern      = `^([0-9A-Fa-f]{2}[:-]){5}(([0-9A-Fa-f]{2}[:-]){2})?[0-9A-Fa-f]{2}$|^([0-9A-Fa-f]{4}\.){2}([0-9A-Fa-f]{4}\.)?[0-9A-Fa-f]{4}$`
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	one = 1
+
+	// shorthandFields are the fields which structs in the config file can be
+	// written as instead of objects.
+	shorthandFields = map[reflect.Type]string{
+		interfaceType: "name",
+		deviceType:    "mac_address",
+	}
+
+	schemaFields = map[string]schemaField{
+		"include": {
+			description: "Glob patterns of config fragments to merge, relative to this file.",
+		},
+		"interval": {
+			description: "How often to detect presence.",
+			pattern:     durationPattern,
+			patternName: "a duration",
+			def:         "30s",
+		},
+		"retrigger_after": {
+			description: "How long after a state change, and then again every this long, to trigger IFTTT again if no further changes occur (0 disables; deprecated in favor of heartbeat.every).",
+			pattern:     durationPattern,
+			patternName: "a duration",
+			def:         "0",
+		},
+		"heartbeat": {
+			description: "How events are retriggered while the household state, and optionally the state of each person, holds.",
+		},
+		"heartbeat.every": {
+			description: "How long after the state changed, and after each heartbeat, to trigger a heartbeat (0 disables heartbeats).",
+			pattern:     durationPattern,
+			patternName: "a duration",
+		},
+		"heartbeat.count": {
+			description: "How many heartbeats are triggered while the state holds (0 is unlimited).",
+		},
+		"heartbeat.people": {
+			description: "Whether to also trigger heartbeats for each device owner while whether they are present holds.",
+		},
+		"flapping": {
+			description: "When the household or a device is flapping and notifications of its changes are suppressed.",
+		},
+		"flapping.transitions": {
+			description: "How many changes within the window are flapping (0 disables flapping detection).",
+		},
+		"flapping.window": {
+			description: "How long changes are counted for and how long flapping lasts after the last change.",
+			pattern:     durationPattern,
+			patternName: "a duration",
+		},
+		"interfaces": {
+			description: "Network interfaces to detect presence on by name, glob pattern or /regular expression/ (default all).",
+			unique:      true,
+		},
+		"interfaces[]": {
+			description: "Interface name or object setting how neighbors on it are probed.",
+		},
+		"interfaces[].name": {
+			description: "Interface name, glob pattern or /regular expression/.",
+		},
+		"interfaces[].ping_count": {
+			description: "Number of pings to send to each device on this interface (default ping_count).",
+			minimum:     &one,
+		},
+		"interfaces[].probe": {
+			description: "How devices are confirmed to be present: ARP pings, ICMP pings or none to trust the neighbor table.",
+			enum:        probes(),
+			def:         string(neighbors.ProbeARPing),
+		},
+		"interfaces[].allowed_ips": {
+			description: "IP addresses or CIDR ranges of the devices to probe (default all).",
+			unique:      true,
+		},
+		"interfaces[].timeout": {
+			description: "How long each probe may take before the device is absent (0 disables).",
+			pattern:     durationPattern,
+			patternName: "a duration",
+			def:         "0",
+		},
+		"exclude_links": {
+			description: "Kinds of links skipped by interface patterns, or none (default loopback, point_to_point and down).",
+			unique:      true,
+		},
+		"exclude_links[]": {
+			enum: append(neighbors.LinkNames(), "none"),
+		},
+		"mac_addresses": {
+			description: "MAC addresses of the devices to detect.",
+			minItems:    &one,
+			unique:      true,
+		},
+		"mac_addresses[]": {
+			description: "MAC address or object describing the device.",
+		},
+		"mac_addresses[].mac_address": {
+			pattern:     macPattern,
+			patternName: "a MAC address",
+		},
+		"mac_addresses[].name": {
+			description: "Name of the device used in logs, notifications and the status (e.g. Alice's Pixel).",
+		},
+		"mac_addresses[].owner": {
+			description: "Person who owns the device.",
+		},
+		"mac_addresses[].type": {
+			description: "Kind of device (e.g. phone or laptop).",
+		},
+		"mac_addresses[].icon": {
+			description: "Name or URL of an icon for the device.",
+		},
+		"mac_addresses[].tags": {
+			description: "Free-form tags.",
+			unique:      true,
+		},
+		"mac_addresses[].role": {
+			description: "How the device counts toward the household being present: always (resident), only in guest mode (guest) or never (ignored).",
+			enum:        roles(),
+			def:         string(RoleResident),
+		},
+		"ping_count": {
+			description: "Number of ARP pings to send to each device.",
+			minimum:     &one,
+			def:         1,
+		},
+		"ifttt": {
+			description: "IFTTT webhooks settings.",
+		},
+		"ifttt.base_url": {
+			description: "IFTTT webhooks base URL.",
+			format:      "uri",
+			def:         defaultBaseURL,
+		},
+		"ifttt.key": {
+			description: "IFTTT webhooks key (${VAR} environment variables are expanded).",
+		},
+		"ifttt.key_file": {
+			description: "File containing the IFTTT webhooks key used instead of key.",
+		},
+		"ifttt.events": {
+			description: "IFTTT events to trigger.",
+		},
+		"ifttt.events.present": {
+			description: "Event triggered when someone becomes present. Values are Go templates.",
+		},
+		"ifttt.events.absent": {
+			description: "Event triggered when everyone becomes absent. Values are Go templates.",
+		},
+		"ifttt.events.flapping": {
+			description: "Event triggered when the household or devices start flapping (not triggered without an event name). Values are Go templates.",
+		},
+		"ifttt.events.present.event": {
+			pattern:     eventName.String(),
+			patternName: "an event name",
+			def:         defaultPresentEvent,
+		},
+		"ifttt.events.absent.event": {
+			pattern:     eventName.String(),
+			patternName: "an event name",
+			def:         defaultAbsentEvent,
+		},
+		"ifttt.events.heartbeat": {
+			description: "Event triggered for heartbeats (the present or absent event is triggered instead without an event name). Values are Go templates.",
+		},
+		"ifttt.events.heartbeat.event": {
+			pattern:     eventName.String(),
+			patternName: "an event name",
+		},
+		"ifttt.events.flapping.event": {
+			pattern:     eventName.String(),
+			patternName: "an event name",
+		},
+		"history": {
+			description: "Where device and household transitions are recorded.",
+		},
+		"history.file": {
+			description: "JSON Lines file transitions are appended to (empty disables the history).",
+		},
+		"history.max_age": {
+			description: "How long transitions are kept (0 keeps them forever).",
+			pattern:     durationPattern,
+			patternName: "a duration",
+		},
+		"history.max_events": {
+			description: "How many transitions are kept (0 keeps all of them).",
+		},
+		"overrides_file": {
+			description: "JSON file manual overrides of devices and people are saved to (empty keeps them in memory only).",
+		},
+		"zones": {
+			description: "Further households detected alongside this one from the same neighbor table.",
+		},
+		"zones[]": {
+			description: "Household with its own interfaces, devices, IFTTT events, heartbeat and flapping detection (unset ones are those of the config).",
+		},
+		"zones[].name": {
+			description: "Name of the zone used in logs, notifications, the status and the history.",
+			pattern:     zoneName.String(),
+			patternName: "a zone name",
+		},
+		"zones[].ifttt": {
+			description: "IFTTT events of the zone (the webhooks key is the config's).",
+		},
+	}
+
+	// zoneFields are the fields which zones have too.
+	zoneFields = []string{"interfaces", "mac_addresses", "heartbeat", "flapping", "ifttt.events"}
+)
+
+func init() {
+	for _, event := range []string{"present", "absent", "flapping", "heartbeat"} {
+		path := "ifttt.events." + event + ".rate_limit"
+		schemaFields[path] = schemaField{
+			description: "Rate limit of the event. Held back triggers are retried until the latest state is delivered.",
+		}
+		schemaFields[path+".events"] = schemaField{
+			description: "How many times the event may be triggered per period (0 is unlimited).",
+		}
+		schemaFields[path+".per"] = schemaField{
+			description: "Period of the rate limit.",
+			pattern:     durationPattern,
+			patternName: "a duration",
+		}
+		schemaFields[path+".min_interval"] = schemaField{
+			description: "How long after the event was triggered it may not be triggered again with identical values.",
+			pattern:     durationPattern,
+			patternName: "a duration",
+		}
+	}
+
+	for path, f := range schemaFields {
+		for _, prefix := range zoneFields {
+			if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[]") {
+				// Zones inherit the config's event names.
+				if strings.HasPrefix(path, "ifttt.events.") {
+					f.def = nil
+				}
+				schemaFields["zones[]."+path] = f
+			}
+		}
+	}
+	schemaFields["zones[].interfaces"] = schemaField{
+		description: "Network interfaces to detect the presence of the zone on by name, glob pattern or /regular expression/.",
+		minItems:    &one,
+		unique:      true,
+	}
+}
+
+// ConfigSchema returns the JSON Schema of the config file. No fields are
+// required since they may be set by environment variables or overrides.
+func ConfigSchema() *Schema {
+	s := schemaOf("", reflect.TypeOf(Config{}))
+	s.Schema = schemaVersion
+	s.Title = "presence configuration"
+	s.Description = "Configuration of the presence daemon."
+	return s
+}
+
+func schemaOf(path string, t reflect.Type) *Schema {
+	s := &Schema{}
+	switch {
+	case t == durationType:
+		s.Type = "string"
+	case t.Kind() == reflect.String:
+		s.Type = "string"
+	case t.Kind() == reflect.Bool:
+		s.Type = "boolean"
+	case t.Kind() == reflect.Uint:
+		s.Type = "integer"
+		s.Minimum = new(int)
+	case t.Kind() == reflect.Slice:
+		s.Type = "array"
+		s.Items = schemaOf(path+"[]", t.Elem())
+	case shorthandFields[t] != "":
+		// Either just the value of one of the fields or an object.
+		name := shorthandFields[t]
+		f, _ := fieldByTag(t, name)
+		s.OneOf = []*Schema{schemaOf(path+"."+name, f.Type), objectSchema(path, t)}
+	case t.Kind() == reflect.Struct:
+		s = objectSchema(path, t)
+	default:
+		panic(fmt.Sprintf("unsupported config type %v", t))
+	}
+
+	f := schemaFields[path]
+	if f.description != "" {
+		s.Description = f.description
+	}
+	if f.pattern != "" {
+		s.Pattern = f.pattern
+		s.patternName = f.patternName
+	}
+	s.Enum = f.enum
+	s.Format = f.format
+	s.Default = f.def
+	if f.minItems != nil {
+		s.MinItems = f.minItems
+	}
+	if f.minimum != nil {
+		s.Minimum = f.minimum
+	}
+	s.UniqueItems = f.unique
+	return s
+}
+
+func objectSchema(path string, t reflect.Type) *Schema {
+	no := false
+	s := &Schema{
+		Type:                 "object",
+		AdditionalProperties: &no,
+		Properties:           make(map[string]*Schema, t.NumField()),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		p := name
+		if path != "" {
+			p = path + "." + name
+		}
+		s.Properties[name] = schemaOf(p, f.Type)
+	}
+	return s
+}
+
+func roles() []string {
+	rs := make([]string, 0, len(Roles))
+	for _, r := range Roles {
+		rs = append(rs, string(r))
+	}
+	return rs
+}
+
+func probes() []string {
+	ps := make([]string, 0, len(neighbors.Probes))
+	for _, p := range neighbors.Probes {
+		ps = append(ps, string(p))
+	}
+	return ps
+}
+
+func (e *SchemaError) Error() string {
+	var position string
+	if e.Line != 0 {
+		position = fmt.Sprintf("line %d, column %d: ", e.Line, e.Column)
+	}
+	if e.Field == "" {
+		return fmt.Sprintf("%v%v", position, e.Message)
+	}
+	return fmt.Sprintf("%v%v: %v", position, e.Field, e.Message)
+}
+
+// ValidateConfig validates the config file and its fragments against the
+// schema, returning all of the values which do not match it. The error is
+// only for failing to read or decode a file.
+func ValidateConfig(name string) ([]*SchemaError, error) {
+	b, errs, err := validateFile(name, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{}
+	_, _ = decodeConfig(name, b, c)
+	files, err := fragments(name, c.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		_, errs, err = validateFile(f, errs)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", f, err)
+		}
+	}
+	return errs, nil
+}
+
+func validateFile(name string, errs []*SchemaError) ([]byte, []*SchemaError, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := &yaml.Node{}
+	switch configFormat(name) {
+	case formatTOML:
+		// TOML has no positions, so it is validated as the equivalent YAML
+		// with errors reported without lines or columns.
+		m := map[string]any{}
+		if _, err = toml.Decode(string(b), &m); err != nil {
+			return nil, nil, err
+		}
+		if err = n.Encode(m); err != nil {
+			return nil, nil, err
+		}
+		clearPositions(n)
+	case formatJSON:
+		if !json.Valid(b) {
+			var v any
+			return nil, nil, fmt.Errorf("json: %w", json.Unmarshal(b, &v))
+		}
+		fallthrough
+	default:
+		if err = yaml.Unmarshal(b, n); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if n.Kind == 0 {
+		return b, errs, nil
+	} else if n.Kind == yaml.DocumentNode {
+		n = n.Content[0]
+	}
+
+	start := len(errs)
+	errs = validate(n, ConfigSchema(), "", errs)
+	for _, e := range errs[start:] {
+		e.File = name
+	}
+	return b, errs, nil
+}
+
+func validate(n *yaml.Node, s *Schema, path string, errs []*SchemaError) []*SchemaError {
+	fail := func(n *yaml.Node, format string, a ...any) {
+		errs = append(errs, &SchemaError{Line: n.Line, Column: n.Column, Field: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	if len(s.OneOf) != 0 {
+		types := make([]string, 0, len(s.OneOf))
+		for _, o := range s.OneOf {
+			if typeMatches(n, o.Type) {
+				return validate(n, o, path, errs)
+			}
+			types = append(types, typeName(o.Type))
+		}
+		fail(n, "expected %v, got %v", strings.Join(types, " or "), kindName(n))
+		return errs
+	}
+
+	switch s.Type {
+	case "object":
+		if n.Kind != yaml.MappingNode {
+			fail(n, "expected an object, got %v", kindName(n))
+			return errs
+		}
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			p := k.Value
+			if path != "" {
+				p = path + "." + k.Value
+			}
+
+			ps, ok := s.Properties[k.Value]
+			if !ok {
+				errs = append(errs, &SchemaError{Line: k.Line, Column: k.Column, Field: p, Message: "unknown field"})
+				continue
+			}
+			errs = validate(v, ps, p, errs)
+		}
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			fail(n, "expected an array, got %v", kindName(n))
+			return errs
+		}
+
+		if s.MinItems != nil && len(n.Content) < *s.MinItems {
+			fail(n, "expected at least %d items, got %d", *s.MinItems, len(n.Content))
+		}
+
+		seen := make(map[string]bool, len(n.Content))
+		for i, c := range n.Content {
+			p := fmt.Sprintf("%v[%d]", path, i)
+			if s.UniqueItems && c.Kind == yaml.ScalarNode {
+				if seen[c.Value] {
+					errs = append(errs, &SchemaError{Line: c.Line, Column: c.Column, Field: p, Message: fmt.Sprintf("duplicate item %#v", c.Value)})
+				}
+				seen[c.Value] = true
+			}
+			errs = validate(c, s.Items, p, errs)
+		}
+	case "string":
+		if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+			fail(n, "expected a string, got %v", kindName(n))
+			return errs
+		}
+		// Durations are only decoded from strings, so even a bare 0 needs
+		// quoting.
+		if s.Pattern == durationPattern && n.Tag != "!!str" {
+			fail(n, "expected %v as a string, got %v", s.patternName, n.Value)
+			return errs
+		}
+
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(n.Value) {
+			if s.patternName != "" {
+				fail(n, "%#v is not %v", n.Value, s.patternName)
+			} else {
+				fail(n, "%#v does not match pattern %v", n.Value, s.Pattern)
+			}
+		}
+
+		if len(s.Enum) != 0 && !slices.Contains(s.Enum, n.Value) {
+			fail(n, "%#v is not one of %v", n.Value, strings.Join(s.Enum, ", "))
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!int" {
+			fail(n, "expected an integer, got %v", kindName(n))
+			return errs
+		}
+
+		i, err := strconv.ParseInt(n.Value, 0, 64)
+		if err != nil {
+			fail(n, "%v", errors.Unwrap(err))
+		} else if s.Minimum != nil && i < int64(*s.Minimum) {
+			fail(n, "expected at least %d, got %d", *s.Minimum, i)
+		}
+	case "boolean":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!bool" {
+			fail(n, "expected a boolean, got %v", kindName(n))
+		}
+	}
+
+	return errs
+}
+
+func clearPositions(n *yaml.Node) {
+	n.Line, n.Column = 0, 0
+	for _, c := range n.Content {
+		clearPositions(c)
+	}
+}
+
+// typeMatches returns whether the node n is of the schema type t, ignoring
+// its value.
+func typeMatches(n *yaml.Node, t string) bool {
+	switch t {
+	case "object":
+		return n.Kind == yaml.MappingNode
+	case "array":
+		return n.Kind == yaml.SequenceNode
+	default:
+		return n.Kind == yaml.ScalarNode && n.Tag != "!!null"
+	}
+}
+
+func typeName(t string) string {
+	switch t {
+	case "object", "array", "integer":
+		return "an " + t
+	default:
+		return "a " + t
+	}
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "an array"
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return "null"
+		}
+		return fmt.Sprintf("%#v", n.Value)
+	default:
+		return "unknown"
+	}
+}
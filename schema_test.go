@@ -0,0 +1,216 @@
+package presence
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	s := ConfigSchema()
+	assert.Equal(schemaVersion, s.Schema)
+	assert.Equal("object", s.Type)
+	if assert.NotNil(s.AdditionalProperties) {
+		assert.False(*s.AdditionalProperties)
+	}
+
+	assert.Equal("string", s.Properties["interval"].Type)
+	assert.Equal(durationPattern, s.Properties["interval"].Pattern)
+	assert.Equal("30s", s.Properties["interval"].Default)
+
+	assert.Equal("array", s.Properties["mac_addresses"].Type)
+	if items := s.Properties["mac_addresses"].Items; assert.Len(items.OneOf, 2) {
+		assert.Equal(macPattern, items.OneOf[0].Pattern)
+		assert.Equal(macPattern, items.OneOf[1].Properties["mac_address"].Pattern)
+		assert.Equal("array", items.OneOf[1].Properties["tags"].Type)
+	}
+	assert.Equal(1, *s.Properties["mac_addresses"].MinItems)
+
+	if items := s.Properties["interfaces"].Items; assert.Len(items.OneOf, 2) {
+		assert.Equal("string", items.OneOf[0].Type)
+		assert.Equal("object", items.OneOf[1].Type)
+		assert.Equal([]string{"arping", "ping", "none"}, items.OneOf[1].Properties["probe"].Enum)
+		assert.Equal(durationPattern, items.OneOf[1].Properties["timeout"].Pattern)
+	}
+
+	assert.Equal("integer", s.Properties["ping_count"].Type)
+	assert.Equal(1, *s.Properties["ping_count"].Minimum)
+
+	event := s.Properties["ifttt"].Properties["events"].Properties["present"].Properties["event"]
+	assert.Equal(eventName.String(), event.Pattern)
+	assert.Equal(defaultPresentEvent, event.Default)
+
+	zone := s.Properties["zones"].Items
+	assert.Equal(zoneName.String(), zone.Properties["name"].Pattern)
+	assert.Equal(macPattern, zone.Properties["mac_addresses"].Items.OneOf[0].Pattern)
+	assert.Nil(zone.Properties["ifttt"].Properties["events"].Properties["present"].Properties["event"].Default)
+}
+
+func TestValidateConfig(t *testing.T) {
+	cases := []struct {
+		name, file string
+		errs       []string
+		err        string
+	}{
+		{
+			name: "success",
+			file: "success.yml",
+		},
+		{
+			name: "defaults",
+			file: "defaults.yml",
+		},
+		{
+			name: "schema errors",
+			file: "schema_errors.yml",
+			errs: []string{
+				`line 1, column 11: interval: expected a duration as a string, got 30`,
+				`line 2, column 20: interfaces[1]: duplicate item "eth0"`,
+				`line 5, column 5: mac_addresses[1]: duplicate item "00:00:00:00:00:01"`,
+				`line 6, column 5: mac_addresses[2]: "not a MAC address" is not a MAC address`,
+				`line 7, column 13: ping_count: expected at least 1, got 0`,
+				`line 8, column 1: unknown: unknown field`,
+				`line 10, column 13: ifttt.base_url: expected a string, got an array`,
+				`line 13, column 14: ifttt.events.present: expected an object, got "presence_detected"`,
+				`line 15, column 11: heartbeat.people: expected a boolean, got "sometimes"`,
+			},
+		},
+		{
+			name: "missing fields",
+			file: "no_mac_addresses.yml",
+		},
+		{
+			name: "zero durations",
+			file: "zero_durations.yml",
+			errs: []string{
+				`line 1, column 11: interval: expected a duration as a string, got 0`,
+			},
+		},
+		{
+			name: "zones",
+			file: "zones.yml",
+		},
+		{
+			name: "zone schema errors",
+			file: "zone_schema_errors.yml",
+			errs: []string{
+				`line 6, column 11: zones[0].name: "rental unit" is not a zone name`,
+				`line 7, column 17: zones[0].interfaces: expected at least 1 items, got 0`,
+				`line 8, column 21: zones[0].mac_addresses[0]: "not a MAC address" is not a MAC address`,
+				`line 10, column 7: zones[0].ifttt.key: unknown field`,
+				`line 12, column 15: zones[0].flapping.window: expected a duration as a string, got 10`,
+			},
+		},
+		{
+			name: "JSON",
+			file: "success.json",
+		},
+		{
+			name: "TOML",
+			file: "success.toml",
+		},
+		{
+			name: "TOML unknown fields",
+			file: "unknown_field.toml",
+			errs: []string{
+				`ifttt.secret: unknown field`,
+				`pingcount: unknown field`,
+			},
+		},
+		{
+			name: "JSON unknown field",
+			file: "unknown_field.json",
+			errs: []string{
+				`line 1, column 42: pingcount: unknown field`,
+			},
+		},
+		{
+			name: "interfaces",
+			file: "interfaces.yml",
+		},
+		{
+			name: "TOML interfaces",
+			file: "interfaces.toml",
+		},
+		{
+			name: "interface schema errors",
+			file: "interface_schema_errors.yml",
+			errs: []string{
+				`line 2, column 5: interfaces[0]: expected a string or an object, got an array`,
+				`line 4, column 12: interfaces[1].probe: "icmp" is not one of arping, ping, none`,
+				`line 5, column 14: interfaces[1].timeout: "soon" is not a duration`,
+				`line 6, column 17: interfaces[1].ping_count: expected at least 1, got 0`,
+				`line 7, column 5: interfaces[1].subnet: unknown field`,
+				`line 10, column 27: exclude_links[1]: "bridge" is not one of loopback, point_to_point, down, none`,
+			},
+		},
+		{
+			name: "devices",
+			file: "devices.yml",
+		},
+		{
+			name: "TOML devices",
+			file: "devices.toml",
+		},
+		{
+			name: "device schema errors",
+			file: "device_schema_errors.yml",
+			errs: []string{
+				`line 3, column 18: mac_addresses[1].mac_address: "not a MAC address" is not a MAC address`,
+				`line 4, column 11: mac_addresses[1].tags: expected an array, got "family"`,
+				`line 5, column 5: mac_addresses[2]: duplicate item "00:00:00:00:00:19"`,
+				`line 6, column 5: mac_addresses[3]: expected a string or an object, got an array`,
+				`line 8, column 11: mac_addresses[4].role: "visitor" is not one of resident, guest, ignored`,
+			},
+		},
+		{
+			name: "nonexistent file",
+			file: "nonexistent.yml",
+			err:  "open tests/nonexistent.yml: no such file or directory",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			errs, err := ValidateConfig(filepath.Join("tests", tc.file))
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+				return
+			}
+
+			if assert.NoError(err) {
+				msgs := make([]string, 0, len(errs))
+				for _, e := range errs {
+					msgs = append(msgs, e.Error())
+				}
+				if len(tc.errs) == 0 {
+					assert.Empty(msgs)
+				} else {
+					assert.Equal(tc.errs, msgs)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Include(t *testing.T) {
+	assert := assert.New(t)
+
+	errs, err := ValidateConfig(filepath.Join("tests", "include_error", "presence.yml"))
+	if assert.NoError(err) && assert.Len(errs, 1) {
+		assert.Equal(&SchemaError{
+			File:    filepath.Join("tests", "include_error", "presence.d", "bad.yml"),
+			Line:    1,
+			Column:  13,
+			Field:   "ping_count",
+			Message: `expected an integer, got "many"`,
+		}, errs[0])
+	}
+}
@@ -0,0 +1,305 @@
+package presence
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// fileOnlyFields can only be set in the config file since they
+	// determine which files are loaded or are lists of objects.
+	fileOnlyFields = map[string]bool{
+		"include": true,
+		"zones":   true,
+	}
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type (
+	// ConfigOverrides are config values keyed by field path (e.g. "ifttt.key")
+	// which take precedence over the environment and the config file.
+	ConfigOverrides map[string]string
+
+	// sources records where each config field path was set from.
+	sources map[string]string
+
+	configField struct {
+		path  string
+		value reflect.Value
+	}
+
+	// elementError is an error in the element at index of a config list,
+	// so that it can be cited with the fragment the element came from.
+	elementError struct {
+		index int
+		err   error
+	}
+)
+
+const (
+	envPrefix = "PRESENCE_"
+
+	sourceDefault = "default"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// ConfigEnv returns the name of the environment variable which overrides
+// the config field path (e.g. PRESENCE_IFTTT_KEY for "ifttt.key").
+func ConfigEnv(path string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+}
+
+// ConfigFields returns the paths of all of the config fields which can be
+// overridden.
+func ConfigFields() []string {
+	fs := configFields("", reflect.ValueOf(&Config{}).Elem(), nil)
+	paths := make([]string, 0, len(fs))
+	for _, f := range fs {
+		if !fileOnlyFields[f.path] {
+			paths = append(paths, f.path)
+		}
+	}
+	return paths
+}
+
+// configFields returns the leaf fields of the config struct v.
+func configFields(prefix string, v reflect.Value, fields []configField) []configField {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct {
+			fields = configFields(name, f, fields)
+		} else {
+			fields = append(fields, configField{path: name, value: f})
+		}
+	}
+	return fields
+}
+
+// override sets the fields of c from the environment and then from the
+// overrides, recording the sources.
+func (s sources) override(c *Config, overrides ConfigOverrides, lookupEnv func(string) (string, bool)) error {
+	fields := configFields("", reflect.ValueOf(c).Elem(), nil)
+	byPath := make(map[string]reflect.Value, len(fields))
+	for _, f := range fields {
+		if fileOnlyFields[f.path] {
+			continue
+		}
+		byPath[f.path] = f.value
+
+		env := ConfigEnv(f.path)
+		if v, ok := lookupEnv(env); ok {
+			if err := setField(f.value, v); err != nil {
+				return fmt.Errorf("%v: %w", env, err)
+			}
+			s.set(f.path, sourceEnv)
+		}
+	}
+
+	paths := make([]string, 0, len(overrides))
+	for p := range overrides {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		f, ok := byPath[p]
+		if !ok {
+			return fmt.Errorf("unknown config field %#v", p)
+		}
+
+		if err := setField(f, overrides[p]); err != nil {
+			return fmt.Errorf("%v: %w", p, err)
+		}
+		s.set(p, sourceFlag)
+	}
+
+	return nil
+}
+
+// file records the fields set in the decoded config file name m.
+func (s sources) file(name, prefix string, m map[string]any) {
+	for k, v := range m {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+
+		if mv, ok := v.(map[string]any); ok {
+			s.file(name, k, mv)
+		} else {
+			s[k] = name
+		}
+	}
+}
+
+// set records the source of the config field path, which replaces the
+// sources of the elements of lists.
+func (s sources) set(path, source string) {
+	for p := range s {
+		if strings.HasPrefix(p, path+"[") {
+			delete(s, p)
+		}
+	}
+	s[path] = source
+}
+
+// element records the source of the element at index of the config list
+// path.
+func (s sources) element(path string, index int, source string) {
+	s[fmt.Sprintf("%v[%d]", path, index)] = source
+}
+
+// elements records the sources of the elements of the lists in c which
+// were set in a single file, so that elements merged from other files can be
+// told apart from them.
+func (s sources) elements(c *Config) {
+	for _, f := range configFields("", reflect.ValueOf(c).Elem(), nil) {
+		source, ok := s[f.path]
+		if !ok || f.value.Kind() != reflect.Slice {
+			continue
+		}
+		for i := range f.value.Len() {
+			s.element(f.path, i, source)
+		}
+	}
+}
+
+// cite prefixes err with the config fragment, other than the config file
+// name, which any of the config field paths (or the element of the list path
+// in an elementError) were set in so that the bad value can be found.
+func (s sources) cite(name string, err error, paths ...string) error {
+	var ee *elementError
+	if errors.As(err, &ee) {
+		paths = []string{fmt.Sprintf("%v[%d]", paths[0], ee.index)}
+	}
+
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, p := range paths {
+		for _, k := range keys {
+			if k != p && !strings.HasPrefix(k, p+".") {
+				continue
+			}
+
+			switch source := s[k]; source {
+			case name, sourceDefault, sourceEnv, sourceFlag:
+			default:
+				return fmt.Errorf("%v: %w", source, err)
+			}
+		}
+	}
+	return err
+}
+
+// of returns the source of the config field path, or the sources of the
+// elements of a list which was merged from several files in order.
+func (s sources) of(path string) string {
+	var sources []string
+	for i := 0; ; i++ {
+		source, ok := s[fmt.Sprintf("%v[%d]", path, i)]
+		if !ok {
+			break
+		}
+		if !slices.Contains(sources, source) {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) > 1 {
+		return strings.Join(sources, ", ")
+	}
+
+	if source, ok := s[path]; ok {
+		return source
+	}
+	return sourceDefault
+}
+
+// precedence ranks the source of the config field path, from defaults up
+// through config files and the environment to flags.
+func (s sources) precedence(path string) int {
+	switch s.of(path) {
+	case sourceDefault:
+		return 0
+	case sourceEnv:
+		return 2
+	case sourceFlag:
+		return 3
+	default:
+		return 1
+	}
+}
+
+func (e *elementError) Error() string {
+	return e.err.Error()
+}
+
+func (e *elementError) Unwrap() error {
+	return e.err
+}
+
+// setField parses value into the field v. Lists are comma separated.
+func setField(v reflect.Value, value string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Uint:
+		u, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var ss []string
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ss = append(ss, s)
+			}
+		}
+		v.Set(reflect.ValueOf(ss))
+	case v.Kind() == reflect.Slice && reflect.PointerTo(v.Type().Elem()).Implements(textUnmarshalerType):
+		vs := reflect.MakeSlice(v.Type(), 0, 0)
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				e := reflect.New(v.Type().Elem())
+				if err := e.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+					return err
+				}
+				vs = reflect.Append(vs, e.Elem())
+			}
+		}
+		v.Set(vs)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
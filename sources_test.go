@@ -0,0 +1,326 @@
+package presence
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/neighbors"
+	mockwrap "douglasthrift.net/presence/wrap/mocks"
+)
+
+func TestConfigEnv(t *testing.T) {
+	assert.Equal(t, "PRESENCE_INTERVAL", ConfigEnv("interval"))
+	assert.Equal(t, "PRESENCE_IFTTT_EVENTS_PRESENT_VALUE1", ConfigEnv("ifttt.events.present.value1"))
+}
+
+func TestConfigFields(t *testing.T) {
+	assert.Equal(t, []string{
+		"interval",
+		"retrigger_after",
+		"heartbeat.every",
+		"heartbeat.count",
+		"heartbeat.people",
+		"flapping.transitions",
+		"flapping.window",
+		"interfaces",
+		"exclude_links",
+		"mac_addresses",
+		"ping_count",
+		"ifttt.base_url",
+		"ifttt.key",
+		"ifttt.key_file",
+		"ifttt.events.present.event",
+		"ifttt.events.present.value1",
+		"ifttt.events.present.value2",
+		"ifttt.events.present.value3",
+		"ifttt.events.present.rate_limit.events",
+		"ifttt.events.present.rate_limit.per",
+		"ifttt.events.present.rate_limit.min_interval",
+		"ifttt.events.absent.event",
+		"ifttt.events.absent.value1",
+		"ifttt.events.absent.value2",
+		"ifttt.events.absent.value3",
+		"ifttt.events.absent.rate_limit.events",
+		"ifttt.events.absent.rate_limit.per",
+		"ifttt.events.absent.rate_limit.min_interval",
+		"ifttt.events.flapping.event",
+		"ifttt.events.flapping.value1",
+		"ifttt.events.flapping.value2",
+		"ifttt.events.flapping.value3",
+		"ifttt.events.flapping.rate_limit.events",
+		"ifttt.events.flapping.rate_limit.per",
+		"ifttt.events.flapping.rate_limit.min_interval",
+		"ifttt.events.heartbeat.event",
+		"ifttt.events.heartbeat.value1",
+		"ifttt.events.heartbeat.value2",
+		"ifttt.events.heartbeat.value3",
+		"ifttt.events.heartbeat.rate_limit.events",
+		"ifttt.events.heartbeat.rate_limit.per",
+		"ifttt.events.heartbeat.rate_limit.min_interval",
+		"history.file",
+		"history.max_age",
+		"history.max_events",
+		"overrides_file",
+	}, ConfigFields())
+}
+
+func TestSources_Of(t *testing.T) {
+	assert := assert.New(t)
+
+	src := sources{}
+	if !assert.NoError(loadConfig("tests/include/presence.yml", &Config{}, src)) {
+		return
+	}
+	assert.Equal("tests/include/presence.yml, tests/include/macs/alice.yml, tests/include/macs/bob.yml, tests/include/presence.d/20-more.json", src.of("mac_addresses"))
+	assert.Equal("tests/include/presence.yml", src.of("interfaces"))
+	assert.Equal("tests/include/macs/bob.yml", src.of("interval"))
+	assert.Equal(sourceDefault, src.of("exclude_links"))
+
+	src.set("mac_addresses", sourceEnv)
+	assert.Equal(sourceEnv, src.of("mac_addresses"))
+}
+
+func TestParseConfigWithOverrides(t *testing.T) {
+	cases := []struct {
+		name, file string
+		env        map[string]string
+		overrides  ConfigOverrides
+		setup      func(t *testing.T, wNet *mockwrap.Net)
+		config     *Config
+		err        string
+	}{
+		{
+			name: "flags over env over file",
+			file: "tests/defaults.yml",
+			env: map[string]string{
+				"PRESENCE_INTERVAL":   "1m",
+				"PRESENCE_PING_COUNT": "2",
+				"PRESENCE_IFTTT_KEY":  "env",
+			},
+			overrides: ConfigOverrides{
+				"ping_count": "3",
+				"interfaces": "eth0, eth1",
+			},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth1", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     time.Minute,
+				Interfaces:   []Interface{{Name: "eth0"}, {Name: "eth1"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:01"}, {MACAddress: "00:00:00:00:00:02"}},
+				PingCount:    3,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "env",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+			},
+		},
+		{
+			name: "no file",
+			env: map[string]string{
+				"PRESENCE_MAC_ADDRESSES": "00-00-00-00-00-0a",
+				"PRESENCE_IFTTT_KEY":     "env",
+			},
+			overrides: ConfigOverrides{
+				"interfaces":                 "eth0",
+				"ifttt.events.absent.event":  "gone",
+				"ifttt.events.absent.value1": "{{.Hostname}}",
+				"heartbeat.every":            "2h",
+				"heartbeat.people":           "true",
+			},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Heartbeat:    Heartbeat{Every: 2 * time.Hour, People: true},
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:0a"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "env",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: "gone", Value1: "{{.Hostname}}"},
+					},
+				},
+			},
+		},
+		{
+			name: "env key over file key_file",
+			file: "tests/ifttt_key_file.yml",
+			env:  map[string]string{"PRESENCE_IFTTT_KEY": "env"},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:15"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "env",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+			},
+		},
+		{
+			name:      "flag key over file key_file",
+			file:      "tests/ifttt_key_file.yml",
+			overrides: ConfigOverrides{"ifttt.key": "flag"},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:15"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "flag",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+			},
+		},
+		{
+			name:      "flag key_file over env key",
+			file:      "tests/ifttt_key_file.yml",
+			env:       map[string]string{"PRESENCE_IFTTT_KEY": "env"},
+			overrides: ConfigOverrides{"ifttt.key_file": "tests/ifttt_key"},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			config: &Config{
+				Interval:     30 * time.Second,
+				Interfaces:   []Interface{{Name: "eth0"}},
+				ExcludeLinks: neighbors.LinkNames(),
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:15"}},
+				PingCount:    1,
+				IFTTT: IFTTT{
+					BaseURL: defaultBaseURL,
+					Key:     "abcdef123456",
+					KeyFile: "tests/ifttt_key",
+					Events: Events{
+						Present: Event{Event: defaultPresentEvent},
+						Absent:  Event{Event: defaultAbsentEvent},
+					},
+				},
+			},
+		},
+		{
+			name: "env key and key_file",
+			file: "tests/ifttt_key_file.yml",
+			env: map[string]string{
+				"PRESENCE_IFTTT_KEY":      "env",
+				"PRESENCE_IFTTT_KEY_FILE": "tests/ifttt_key",
+			},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "both IFTTT key and key_file",
+		},
+		{
+			name: "env replaces fragment list",
+			file: "tests/include_invalid_device/presence.yml",
+			env:  map[string]string{"PRESENCE_MAC_ADDRESSES": "00:00:00:00:00:01,00:00:00:00:00:01"},
+			setup: func(t *testing.T, wNet *mockwrap.Net) {
+				wNet.AddInterfaceByName(func(name string) (*net.Interface, error) {
+					assert.Equal(t, "eth0", name)
+					return &net.Interface{}, nil
+				})
+			},
+			err: "duplicate MAC address (00:00:00:00:00:01)",
+		},
+		{
+			name: "invalid env",
+			file: "tests/defaults.yml",
+			env:  map[string]string{"PRESENCE_PING_COUNT": "x"},
+			err:  `PRESENCE_PING_COUNT: strconv.ParseUint: parsing "x": invalid syntax`,
+		},
+		{
+			name:      "invalid flag",
+			file:      "tests/defaults.yml",
+			overrides: ConfigOverrides{"retrigger_after": "soon"},
+			err:       `retrigger_after: time: invalid duration "soon"`,
+		},
+		{
+			name:      "invalid bool flag",
+			file:      "tests/defaults.yml",
+			overrides: ConfigOverrides{"heartbeat.people": "sometimes"},
+			err:       `heartbeat.people: strconv.ParseBool: parsing "sometimes": invalid syntax`,
+		},
+		{
+			name:      "unknown flag",
+			file:      "tests/defaults.yml",
+			overrides: ConfigOverrides{"ifttt.secret": "x"},
+			err:       `unknown config field "ifttt.secret"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			wNet := mockwrap.NewNet(t)
+			if tc.setup != nil {
+				tc.setup(t, wNet)
+			}
+
+			c, err := ParseConfigWithOverrides(context.Background(), tc.file, tc.overrides, wNet)
+			if tc.err != "" {
+				assert.EqualError(err, tc.err)
+			} else if assert.NoError(err) {
+				assert.Equal(tc.config, c)
+			}
+
+			assert.False(wNet.HasMore(), "missing expected net calls")
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package presence
+
+import (
+	"time"
+)
+
+type (
+	// Status is a snapshot of the detected presence.
+	Status struct {
+		Present bool `json:"present"`
+		// Since is when the household state last changed. It is zero when
+		// unknown.
+		Since time.Time `json:"since"`
+		// Flapping is whether the household is flapping.
+		Flapping bool `json:"flapping,omitempty"`
+		// GuestMode is whether guest devices counted toward the household.
+		GuestMode bool `json:"guest_mode,omitempty"`
+		// Suppressed counts the triggers of each IFTTT event held back by
+		// its rate limit.
+		Suppressed map[string]uint `json:"suppressed,omitempty"`
+		// Notifier is the health of the IFTTT notifications.
+		Notifier NotifierStatus `json:"notifier"`
+		Devices  []DeviceStatus `json:"devices"`
+		// Zone is the name of the zone, or empty for the household of the
+		// config, whose status has the statuses of the zones in Zones.
+		Zone  string    `json:"zone,omitempty"`
+		Zones []*Status `json:"zones,omitempty"`
+	}
+
+	// NotifierStatus is a snapshot of the health of the IFTTT
+	// notifications. Triggers held back by a rate limit are neither
+	// successes nor failures.
+	NotifierStatus struct {
+		// LastTriggered is when an event was last triggered successfully
+		// and LastEvent which one it was.
+		LastTriggered time.Time `json:"last_triggered,omitzero"`
+		LastEvent     string    `json:"last_event,omitempty"`
+		// LastError is the error of the last failed trigger and
+		// LastFailed when it failed.
+		LastError  string    `json:"last_error,omitempty"`
+		LastFailed time.Time `json:"last_failed,omitzero"`
+		// Failures counts the triggers which have failed since the last
+		// successful one.
+		Failures uint `json:"failures,omitempty"`
+	}
+
+	// DeviceStatus is a snapshot of the detected presence of a device.
+	DeviceStatus struct {
+		MACAddress string   `json:"mac_address"`
+		Name       string   `json:"name,omitempty"`
+		Owner      string   `json:"owner,omitempty"`
+		Type       string   `json:"type,omitempty"`
+		Icon       string   `json:"icon,omitempty"`
+		Tags       []string `json:"tags,omitempty"`
+		Role       string   `json:"role"`
+		Present    bool     `json:"present"`
+		Interface  string   `json:"interface,omitempty"`
+		// Since is when the device's state was first detected or last
+		// changed. It is zero when unknown.
+		Since time.Time `json:"since"`
+		// LastSeen is when the device was last detected present. It is
+		// zero when it has not been.
+		LastSeen time.Time `json:"last_seen,omitzero"`
+		// Override is the override of the device's state. It is nil when
+		// its state is as detected.
+		Override *StateOverride `json:"override,omitempty"`
+		Flapping bool           `json:"flapping,omitempty"`
+	}
+)
+
+// Healthy returns whether the last trigger did not fail.
+func (n NotifierStatus) Healthy() bool {
+	return n.Failures == 0
+}
@@ -0,0 +1,99 @@
+package presence
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"goa.design/clue/log"
+)
+
+type (
+	// Change is a change of the presence of the household or of a device.
+	// The first detection of a state is a change from the opposite one.
+	Change struct {
+		Time time.Time `json:"time"`
+		// MACAddress is the device which changed. It is empty for the
+		// household.
+		MACAddress string `json:"mac_address,omitempty"`
+		Name       string `json:"name,omitempty"`
+		Owner      string `json:"owner,omitempty"`
+		Interface  string `json:"interface,omitempty"`
+		// Was is the presence before the change and Present after it.
+		Was     bool `json:"was"`
+		Present bool `json:"present"`
+		// Source is what made the change (e.g. ChangeSourceDetection).
+		Source string `json:"source"`
+		// Zone is the zone of the household or device, or empty for the
+		// household of the config.
+		Zone string `json:"zone,omitempty"`
+	}
+
+	// subscribers are the channels changes are sent to.
+	subscribers struct {
+		mu    sync.Mutex
+		chans map[chan Change]struct{}
+	}
+)
+
+const (
+	// ChangeSourceDetection is the source of changes found by detecting
+	// presence.
+	ChangeSourceDetection = "detection"
+	// ChangeSourceOverride is the source of changes made by overriding the
+	// state of devices or people.
+	ChangeSourceOverride = "override"
+	// ChangeSourceGuestMode is the source of changes of the household made
+	// by toggling guest mode.
+	ChangeSourceGuestMode = "guest_mode"
+
+	// changeBuffer is how many changes a subscriber can fall behind before
+	// they are dropped.
+	changeBuffer = 64
+)
+
+// Household returns whether the change is of the household rather than a
+// device.
+func (c Change) Household() bool {
+	return c.MACAddress == ""
+}
+
+// subscribe returns a channel of changes which is closed once ctx is done.
+func (s *subscribers) subscribe(ctx context.Context) <-chan Change {
+	c := make(chan Change, changeBuffer)
+
+	s.mu.Lock()
+	if s.chans == nil {
+		s.chans = make(map[chan Change]struct{})
+	}
+	s.chans[c] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.chans, c)
+		close(c)
+	}()
+	return c
+}
+
+// publish sends the changes to every subscriber without waiting, dropping
+// those a subscriber has no room for.
+func (s *subscribers) publish(ctx context.Context, changes []Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.chans {
+		for _, change := range changes {
+			select {
+			case c <- change:
+			default:
+				log.Warn(ctx, log.KV{K: "msg", V: "dropped change for slow subscriber"},
+					log.KV{K: "MAC address", V: change.MACAddress}, log.KV{K: "present", V: change.Present})
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package presence
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribers(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		subs        subscribers
+		slow        = subs.subscribe(ctx)
+		changes     = make([]Change, changeBuffer+1)
+	)
+	for i := range changes {
+		changes[i].Present = i%2 == 0
+	}
+
+	// Changes a subscriber has no room for are dropped.
+	subs.publish(ctx, changes)
+	assert.Len(slow, changeBuffer)
+	for _, change := range changes[:changeBuffer] {
+		assert.Equal(change, <-slow)
+	}
+
+	cancel()
+	for range slow {
+	}
+	subs.mu.Lock()
+	assert.Empty(subs.chans)
+	subs.mu.Unlock()
+
+	// Publishing after unsubscribing does not send on the closed channel.
+	subs.publish(context.Background(), changes)
+}
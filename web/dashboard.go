@@ -0,0 +1,22 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboard embed.FS
+
+// NewDashboard returns a handler serving the dashboard page, which shows
+// the status, recent history and notifier health served alongside it at
+// status, history and events relative to its own path. All of its assets
+// are embedded so that it works without internet access.
+func NewDashboard() http.Handler {
+	root, err := fs.Sub(dashboard, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServerFS(root)
+}
@@ -0,0 +1,265 @@
+// The dashboard renders the status and history served alongside it and keeps
+// them up to date from the events stream.
+"use strict";
+
+const historyLimit = 20;
+
+let status = null;
+let events = [];
+let historyEnabled = true;
+let statusTimeout = null;
+
+function $(id) {
+  return document.getElementById(id);
+}
+
+function element(tag, text, className) {
+  const e = document.createElement(tag);
+  if (text !== undefined) {
+    e.textContent = text;
+  }
+  if (className) {
+    e.className = className;
+  }
+  return e;
+}
+
+function badge(e, text, className) {
+  e.textContent = text;
+  e.className = "badge " + className;
+}
+
+// ago returns how long ago the time was, or undefined when it is unknown.
+function ago(time) {
+  if (!time || time.startsWith("0001-")) {
+    return undefined;
+  }
+  const seconds = Math.max(0, Math.round((Date.now() - Date.parse(time)) / 1000));
+  if (seconds < 60) {
+    return seconds + "s ago";
+  }
+  const minutes = Math.floor(seconds / 60);
+  if (minutes < 60) {
+    return minutes + "m ago";
+  }
+  const hours = Math.floor(minutes / 60);
+  if (hours < 48) {
+    return hours + "h " + (minutes % 60) + "m ago";
+  }
+  return Math.floor(hours / 24) + "d ago";
+}
+
+function timeElement(time, fallback) {
+  const text = ago(time);
+  if (text === undefined) {
+    return element("span", fallback || "never", "muted");
+  }
+  const e = element("time", text);
+  e.dateTime = time;
+  e.title = new Date(time).toLocaleString();
+  return e;
+}
+
+function label(device) {
+  return device.name || device.mac_address;
+}
+
+// householdBadge shows whether the household or zone is home on the element.
+function householdBadge(e, household) {
+  if (household.flapping) {
+    badge(e, "flapping", "flapping");
+  } else if (household.present) {
+    badge(e, "home", "present");
+  } else {
+    badge(e, "away", "absent");
+  }
+  return e;
+}
+
+function renderStatus() {
+  if (!status) {
+    return;
+  }
+
+  householdBadge($("household-state"), status);
+  $("household-since").replaceChildren(timeElement(status.since, ""));
+  $("guest-mode").hidden = !status.guest_mode;
+
+  const zones = status.zones || [];
+  const zoneList = $("zone-list");
+  zoneList.replaceChildren();
+  for (const zone of zones) {
+    const li = element("li");
+    const home = zone.devices.filter((device) => device.present).length;
+    li.append(householdBadge(element("span"), zone), " " + zone.zone + " ", timeElement(zone.since, ""),
+      element("span", " " + home + " of " + zone.devices.length + " devices present", "muted"));
+    zoneList.append(li);
+  }
+  $("zones").hidden = zones.length === 0;
+
+  const people = new Map();
+  for (const device of status.devices) {
+    // Ignored devices do not make their owners home.
+    if (device.owner && device.role !== "ignored") {
+      people.set(device.owner, people.get(device.owner) || device.present);
+    }
+  }
+  const peopleList = $("people-list");
+  peopleList.replaceChildren();
+  for (const owner of [...people.keys()].sort()) {
+    const li = element("li");
+    li.append(element("span", people.get(owner) ? "home" : "away", "badge " + (people.get(owner) ? "present" : "absent")), " ", owner);
+    peopleList.append(li);
+  }
+  if (people.size === 0) {
+    peopleList.append(element("li", "No device owners configured.", "muted"));
+  }
+
+  const rows = $("device-rows");
+  rows.replaceChildren();
+  for (const device of status.devices) {
+    const tr = element("tr");
+    const name = element("td", label(device));
+    name.title = device.mac_address;
+    if (device.role && device.role !== "resident") {
+      name.append(element("span", " " + device.role, "muted"));
+    }
+    let className = device.present ? "present" : "absent";
+    if (device.flapping) {
+      className = "flapping";
+    }
+    const state = element("td");
+    state.append(element("span", device.flapping ? "flapping" : device.present ? "present" : "absent", "badge " + className));
+    if (device.override) {
+      const override = element("span", " overridden", "muted");
+      override.title = (device.override.reason ? device.override.reason + ", " : "") +
+        (device.override.until ? "until " + new Date(device.override.until).toLocaleString() : "until cleared");
+      state.append(override);
+    }
+    const since = element("td");
+    since.append(timeElement(device.since, "unknown"));
+    const lastSeen = element("td");
+    lastSeen.append(device.present ? element("span", "now") : timeElement(device.last_seen));
+    tr.append(name, element("td", device.owner || ""), state, since, lastSeen, element("td", device.interface || ""));
+    rows.append(tr);
+  }
+
+  const notifier = status.notifier || {};
+  const detail = $("notifier-detail");
+  if (notifier.failures) {
+    badge($("notifier-state"), "failing", "failing");
+    detail.replaceChildren(notifier.failures + " failed since the last success, most recently ", timeElement(notifier.last_failed), ": " + notifier.last_error);
+  } else if (notifier.last_triggered) {
+    badge($("notifier-state"), "healthy", "healthy");
+    detail.replaceChildren("Last triggered " + notifier.last_event + " ", timeElement(notifier.last_triggered));
+  } else {
+    badge($("notifier-state"), "idle", "unknown");
+    detail.replaceChildren("Nothing triggered yet.");
+  }
+  const suppressed = $("suppressed-list");
+  suppressed.replaceChildren();
+  for (const [event, count] of Object.entries(status.suppressed || {}).sort()) {
+    suppressed.append(element("li", event + ": " + count + " suppressed by rate limit", "muted"));
+  }
+}
+
+function renderHistory() {
+  const empty = $("history-empty");
+  const list = $("history-list");
+  list.replaceChildren();
+  if (!historyEnabled) {
+    empty.textContent = "No history file configured.";
+    empty.hidden = false;
+    return;
+  }
+  empty.textContent = "No transitions recorded yet.";
+  empty.hidden = events.length !== 0;
+  for (const event of events) {
+    const li = element("li");
+    const who = event.mac_address ? label(event) : event.zone || "Household";
+    li.append(timeElement(event.time), " ", who + (event.present ? " arrived" : " left"));
+    if (event.interface) {
+      li.append(element("span", " on " + event.interface, "muted"));
+    }
+    if (event.mac_address && event.zone) {
+      li.append(element("span", " in " + event.zone, "muted"));
+    }
+    list.append(li);
+  }
+}
+
+async function fetchJSON(path) {
+  const resp = await fetch(path, { cache: "no-store" });
+  if (!resp.ok) {
+    const error = new Error(path + ": " + resp.status + " " + resp.statusText);
+    error.status = resp.status;
+    throw error;
+  }
+  return resp.json();
+}
+
+async function loadStatus() {
+  try {
+    status = await fetchJSON("status");
+    renderStatus();
+  } catch (error) {
+    console.error(error);
+  }
+}
+
+async function loadHistory() {
+  try {
+    // The history is served oldest first.
+    events = (await fetchJSON("history?limit=" + historyLimit)).reverse();
+    historyEnabled = true;
+  } catch (error) {
+    if (error.status === 404) {
+      historyEnabled = false;
+    } else {
+      console.error(error);
+    }
+  }
+  renderHistory();
+}
+
+function connect() {
+  const source = new EventSource("events");
+  source.onopen = () => {
+    badge($("connection"), "live", "connected");
+    loadHistory();
+  };
+  source.onerror = () => {
+    badge($("connection"), "reconnecting", "reconnecting");
+  };
+  source.addEventListener("status", (e) => {
+    status = JSON.parse(e.data);
+    renderStatus();
+  });
+  source.addEventListener("change", (e) => {
+    const change = JSON.parse(e.data);
+    if (historyEnabled) {
+      events.unshift({
+        time: change.time,
+        mac_address: change.mac_address,
+        name: change.name,
+        owner: change.owner,
+        present: change.present,
+        interface: change.interface,
+        zone: change.zone,
+      });
+      events.length = Math.min(events.length, historyLimit);
+      renderHistory();
+    }
+    // The change does not carry the rest of the status, such as the last
+    // seen times and notifier health, so fetch it again once the detection
+    // which found it (and any other changes) has finished.
+    clearTimeout(statusTimeout);
+    statusTimeout = setTimeout(loadStatus, 1000);
+  });
+}
+
+connect();
+setInterval(() => {
+  renderStatus();
+  renderHistory();
+}, 30000);
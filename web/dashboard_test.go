@@ -0,0 +1,57 @@
+package web
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(NewDashboard())
+	defer server.Close()
+
+	for path, contentType := range map[string]string{
+		"/":              "text/html; charset=utf-8",
+		"/dashboard.css": "text/css; charset=utf-8",
+		"/dashboard.js":  "text/javascript; charset=utf-8",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if assert.NoError(err, path) {
+			assert.Equal(http.StatusOK, resp.StatusCode, path)
+			assert.Equal(contentType, resp.Header.Get("Content-Type"), path)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/missing.js")
+	if assert.NoError(err) {
+		assert.Equal(http.StatusNotFound, resp.StatusCode)
+		_ = resp.Body.Close()
+	}
+}
+
+// TestDashboard_NoExternalAssets checks that the dashboard only refers to
+// its own assets so that it works on a network without internet access.
+func TestDashboard_NoExternalAssets(t *testing.T) {
+	external := regexp.MustCompile(`(?i)(https?:)?//[a-z0-9.-]+\.[a-z]{2,}|@import|url\(`)
+	err := fs.WalkDir(dashboard, "dashboard", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := fs.ReadFile(dashboard, path)
+		if err != nil {
+			return err
+		}
+		assert.Empty(t, external.FindAllString(string(b), -1), path)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,87 @@
+// Package web serves presence over HTTP for browsers.
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence"
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// Events streams the status of a detector and then each change as
+	// Server-Sent Events, with keepalive comments while nothing changes.
+	Events struct {
+		detector  presence.Detector
+		clock     wrap.Clock
+		keepalive time.Duration
+	}
+)
+
+const (
+	// StatusEvent is the type of the event with the status sent on
+	// connect.
+	StatusEvent = "status"
+	// ChangeEvent is the type of the events with each change.
+	ChangeEvent = "change"
+)
+
+// NewEvents returns a handler streaming the events of the detector, which
+// sends a keepalive comment every keepalive using the clock.
+func NewEvents(detector presence.Detector, clock wrap.Clock, keepalive time.Duration) *Events {
+	return &Events{
+		detector:  detector,
+		clock:     clock,
+		keepalive: keepalive,
+	}
+}
+
+func (e *Events) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ctx := r.Context()
+	changes := e.detector.Subscribe(ctx)
+	ticker := e.clock.NewTicker(e.keepalive)
+	defer ticker.Stop()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	err := writeEvent(w, StatusEvent, e.detector.Status())
+	for err == nil {
+		flusher.Flush()
+
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			err = writeEvent(w, ChangeEvent, change)
+		case <-ticker.C():
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		}
+	}
+	log.Debug(ctx, log.KV{K: "msg", V: "stopped streaming events"}, log.KV{K: "error", V: err})
+}
+
+// writeEvent writes an event of the type with the value encoded as JSON.
+func writeEvent(w http.ResponseWriter, typ string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %v\ndata: %s\n\n", typ, data)
+	return err
+}
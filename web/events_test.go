@@ -0,0 +1,83 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence"
+	mockpresence "douglasthrift.net/presence/mocks"
+	"douglasthrift.net/presence/wrap"
+)
+
+func TestEvents(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		start    = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+		clock    = wrap.NewFakeClock(start)
+		detector = mockpresence.NewDetector(t)
+		changes  = make(chan presence.Change)
+		server   = httptest.NewServer(NewEvents(detector, clock, 15*time.Second))
+	)
+	defer server.Close()
+
+	subscribed := make(chan context.Context, 1)
+	detector.AddSubscribe(func(ctx context.Context) <-chan presence.Change {
+		subscribed <- ctx
+		return changes
+	})
+	detector.AddStatus(func() *presence.Status {
+		return &presence.Status{Present: true, Since: start, Devices: []presence.DeviceStatus{}}
+	})
+
+	resp, err := http.Get(server.URL)
+	if !assert.NoError(err) {
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal("no-cache", resp.Header.Get("Cache-Control"))
+
+	var (
+		r    = bufio.NewReader(resp.Body)
+		next = func() string {
+			var lines []string
+			for {
+				line, err := r.ReadString('\n')
+				if !assert.NoError(err) || line == "\n" {
+					return strings.Join(lines, "")
+				}
+				lines = append(lines, line)
+			}
+		}
+	)
+
+	assert.Equal("event: status\ndata: {\"present\":true,\"since\":\"2022-03-04T08:00:00Z\",\"notifier\":{},\"devices\":[]}\n", next())
+
+	clock.Advance(15 * time.Second)
+	assert.Equal(": keepalive\n", next())
+
+	changes <- presence.Change{Time: start, MACAddress: "00:00:00:00:00:01", Present: true, Source: presence.ChangeSourceDetection}
+	assert.Equal("event: change\ndata: {\"time\":\"2022-03-04T08:00:00Z\",\"mac_address\":\"00:00:00:00:00:01\",\"was\":false,\"present\":true,\"source\":\"detection\"}\n", next())
+
+	// Disconnecting ends the subscription.
+	ctx := <-subscribed
+	_ = resp.Body.Close()
+	<-ctx.Done()
+	close(changes)
+
+	assert.False(detector.HasMore(), "missing expected detector calls")
+}
+
+func TestEvents_StreamingUnsupported(t *testing.T) {
+	w := &struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	NewEvents(mockpresence.NewDetector(t), wrap.NewFakeClock(time.Time{}), time.Second).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.ResponseWriter.(*httptest.ResponseRecorder).Code)
+}
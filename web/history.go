@@ -0,0 +1,70 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/history"
+)
+
+type (
+	// History serves the most recent transitions in a history as JSON.
+	History struct {
+		source func() history.History
+	}
+)
+
+const (
+	// DefaultHistoryLimit is how many transitions are served when the
+	// request does not set a limit.
+	DefaultHistoryLimit = 50
+)
+
+// NewHistory returns a handler serving the transitions in the history
+// returned by source, which is nil when there is none, so that it follows
+// the history across reloads.
+func NewHistory(source func() history.History) *History {
+	return &History{source: source}
+}
+
+// ServeHTTP serves the last transitions, up to the limit query parameter and
+// of the zone query parameter when it is set, in the order they were
+// recorded.
+func (h *History) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limit := DefaultHistoryLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit "+strconv.Quote(l), http.StatusBadRequest)
+			return
+		}
+	}
+
+	hist := h.source()
+	if hist == nil {
+		http.Error(w, "no history file configured", http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	events, err := hist.Query(history.Filter{Zone: r.URL.Query().Get("zone")})
+	if err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error reading history"})
+		http.Error(w, "error reading history", http.StatusInternalServerError)
+		return
+	}
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	if events == nil {
+		events = []history.Event{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(events); err != nil {
+		log.Error(ctx, err, log.KV{K: "msg", V: "error encoding history"})
+	}
+}
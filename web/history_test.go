@@ -0,0 +1,121 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"douglasthrift.net/presence/history"
+	mockhistory "douglasthrift.net/presence/history/mocks"
+)
+
+func TestHistory(t *testing.T) {
+	start := time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+	events := make([]history.Event, DefaultHistoryLimit+2)
+	for i := range events {
+		events[i] = history.Event{Time: start.Add(time.Duration(i) * time.Minute), Present: i%2 == 0}
+	}
+
+	cases := []struct {
+		name    string
+		query   string
+		filter  history.Filter
+		none    bool
+		events  []history.Event
+		err     error
+		code    int
+		body    string
+		matches []history.Event
+	}{
+		{
+			name:    "default limit",
+			events:  events,
+			code:    http.StatusOK,
+			matches: events[2:],
+		},
+		{
+			name:    "limit",
+			query:   "?limit=2",
+			events:  events,
+			code:    http.StatusOK,
+			matches: events[len(events)-2:],
+		},
+		{
+			name:    "limit beyond history",
+			query:   "?limit=100",
+			events:  events[:3],
+			code:    http.StatusOK,
+			matches: events[:3],
+		},
+		{
+			name:    "zone",
+			query:   "?zone=Cottage&limit=2",
+			filter:  history.Filter{Zone: "Cottage"},
+			events:  events[:3],
+			code:    http.StatusOK,
+			matches: events[1:3],
+		},
+		{
+			name:    "empty",
+			code:    http.StatusOK,
+			matches: []history.Event{},
+		},
+		{
+			name:  "invalid limit",
+			query: "?limit=-1",
+			code:  http.StatusBadRequest,
+			body:  "invalid limit \"-1\"\n",
+		},
+		{
+			name: "no history",
+			none: true,
+			code: http.StatusNotFound,
+			body: "no history file configured\n",
+		},
+		{
+			name: "error",
+			err:  errors.New("corrupt"),
+			code: http.StatusInternalServerError,
+			body: "error reading history\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			h := mockhistory.NewHistory(t)
+			if tc.code != http.StatusBadRequest && !tc.none {
+				h.AddQuery(func(filter history.Filter) ([]history.Event, error) {
+					assert.Equal(tc.filter, filter)
+					return tc.events, tc.err
+				})
+			}
+			source := func() history.History {
+				if tc.none {
+					return nil
+				}
+				return h
+			}
+
+			w := httptest.NewRecorder()
+			NewHistory(source).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history"+tc.query, nil))
+			assert.Equal(tc.code, w.Code)
+			if tc.matches != nil {
+				assert.Equal("application/json", w.Header().Get("Content-Type"))
+				want, err := json.Marshal(tc.matches)
+				if assert.NoError(err) {
+					assert.JSONEq(string(want), w.Body.String())
+				}
+			} else {
+				assert.Equal(tc.body, w.Body.String())
+			}
+			assert.False(h.HasMore(), "missing expected history calls")
+		})
+	}
+}
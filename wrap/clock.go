@@ -0,0 +1,63 @@
+package wrap
+
+import (
+	"time"
+)
+
+type (
+	// Clock tells the time and makes tickers, so that anything timed can be
+	// tested with a FakeClock instead of waiting. It is only implemented by
+	// the clocks of this package, so it has no mock.
+	Clock interface {
+		Now() time.Time
+		Since(t time.Time) time.Duration
+		NewTicker(d time.Duration) Ticker
+		isClock()
+	}
+
+	// Ticker is a time.Ticker made by a Clock.
+	Ticker interface {
+		C() <-chan time.Time
+		Reset(d time.Duration)
+		Stop()
+		isTicker()
+	}
+
+	clockImpl struct{}
+
+	tickerImpl struct {
+		ticker *time.Ticker
+	}
+)
+
+func NewClock() Clock {
+	return &clockImpl{}
+}
+
+func (*clockImpl) Now() time.Time {
+	return time.Now()
+}
+
+func (*clockImpl) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (*clockImpl) NewTicker(d time.Duration) Ticker {
+	return &tickerImpl{ticker: time.NewTicker(d)}
+}
+
+func (*clockImpl) isClock() {}
+
+func (t *tickerImpl) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *tickerImpl) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+}
+
+func (t *tickerImpl) Stop() {
+	t.ticker.Stop()
+}
+
+func (*tickerImpl) isTicker() {}
@@ -0,0 +1,116 @@
+package wrap
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// FakeClock is a Clock whose time only moves when it is advanced, which
+	// fires any of its tickers that come due like a time.Ticker would,
+	// dropping ticks for slow receivers.
+	FakeClock struct {
+		mu      sync.Mutex
+		now     time.Time
+		tickers []*fakeTicker
+	}
+
+	fakeTicker struct {
+		clock  *FakeClock
+		c      chan time.Time
+		period time.Duration
+		// next is when the ticker next fires. It is zero once stopped.
+		next time.Time
+	}
+)
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (*FakeClock) isClock() {}
+
+// NewTicker returns a ticker which fires every d as the clock is advanced.
+// It panics if d is not positive, like time.NewTicker.
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1), period: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing its tickers at each time
+// they come due along the way.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		var due *fakeTicker
+		for _, t := range c.tickers {
+			if !t.next.IsZero() && !t.next.After(end) && (due == nil || t.next.Before(due.next)) {
+				due = t
+			}
+		}
+		if due == nil {
+			break
+		}
+
+		c.now = due.next
+		due.next = due.next.Add(due.period)
+		select {
+		case due.c <- c.now:
+		default:
+		}
+	}
+	c.now = end
+}
+
+// Set moves the clock to now, firing its tickers like Advance when now is
+// later.
+func (c *FakeClock) Set(now time.Time) {
+	c.Advance(now.Sub(c.Now()))
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.period, t.next = d, t.clock.now.Add(d)
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.next = time.Time{}
+}
+
+func (*fakeTicker) isTicker() {}
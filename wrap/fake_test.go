@@ -0,0 +1,51 @@
+package wrap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		start = time.Date(2022, time.March, 4, 8, 0, 0, 0, time.UTC)
+		clock = NewFakeClock(start)
+		ticks = func(ticker Ticker) (ts []time.Time) {
+			for {
+				select {
+				case t := <-ticker.C():
+					ts = append(ts, t)
+				default:
+					return
+				}
+			}
+		}
+	)
+
+	assert.Equal(start, clock.Now())
+	clock.Advance(time.Minute)
+	assert.Equal(start.Add(time.Minute), clock.Now())
+	assert.Equal(time.Minute, clock.Since(start))
+
+	ticker := clock.NewTicker(30 * time.Second)
+	clock.Advance(29 * time.Second)
+	assert.Empty(ticks(ticker))
+	clock.Advance(time.Second)
+	assert.Equal([]time.Time{start.Add(90 * time.Second)}, ticks(ticker))
+
+	// Ticks are dropped while nobody receives them.
+	clock.Advance(2 * time.Minute)
+	assert.Equal([]time.Time{start.Add(2 * time.Minute)}, ticks(ticker))
+
+	ticker.Reset(time.Minute)
+	clock.Set(start.Add(5 * time.Minute))
+	assert.Equal([]time.Time{start.Add(270 * time.Second)}, ticks(ticker))
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	assert.Empty(ticks(ticker))
+	assert.Equal(start.Add(65*time.Minute), clock.Now())
+}
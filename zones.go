@@ -0,0 +1,250 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"douglasthrift.net/presence/history"
+	"douglasthrift.net/presence/ifttt"
+	"douglasthrift.net/presence/neighbors"
+	"douglasthrift.net/presence/wrap"
+)
+
+type (
+	// zonedDetector detects the household of the config and then those of
+	// its zones from the same dump of the neighbor table. The zones share
+	// the overrides, guest mode and subscribers of the household.
+	zonedDetector struct {
+		*detector
+		// clients are the IFTTT clients of the zones by name.
+		clients map[string]ifttt.Client
+
+		mu    sync.RWMutex
+		zones []*detector
+	}
+)
+
+var zoneName = regexp.MustCompile("^[-_a-zA-Z0-9]+$")
+
+// newZonedDetector returns a detector of the household of the runtime's
+// config and its zones.
+func newZonedDetector(rt *Runtime, clock wrap.Clock) *zonedDetector {
+	z := &zonedDetector{detector: NewDetector(rt.Config, rt.ARP, rt.Client, clock).(*detector)}
+	z.Runtime(rt)
+	return z
+}
+
+// Detect detects the presence of the household and then of each zone,
+// dumping the neighbor table only once. A household with zones but no
+// devices of its own is not detected. A zone failing does not keep the
+// others from being detected.
+func (z *zonedDetector) Detect(ctx context.Context) error {
+	ctx = neighbors.WithSharedTable(ctx)
+	var errs []error
+	if len(z.config.MACAddresses) != 0 || len(z.zones) == 0 {
+		errs = append(errs, z.detector.Detect(ctx))
+	}
+	for _, zd := range z.zones {
+		if err := zd.Detect(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("zone %v: %w", zd.zone, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (z *zonedDetector) Config(config *Config) {
+	z.detector.Config(config)
+	z.updateZones()
+}
+
+func (z *zonedDetector) Client(client ifttt.Client) {
+	z.detector.Client(client)
+	z.updateZones()
+}
+
+func (z *zonedDetector) History(h history.History) {
+	z.detector.History(h)
+	z.updateZones()
+}
+
+// Runtime replaces the config, ARP, IFTTT clients and history of the
+// household and its zones together.
+func (z *zonedDetector) Runtime(rt *Runtime) {
+	z.detector.Runtime(rt)
+	z.clients = rt.ZoneClients
+	z.updateZones()
+}
+
+// updateZones makes the detectors of the zones match the zones of the
+// config, keeping the state of the zones which are still there.
+func (z *zonedDetector) updateZones() {
+	old := make(map[string]*detector, len(z.zones))
+	for _, zd := range z.zones {
+		old[zd.zone] = zd
+	}
+
+	zones := make([]*detector, 0, len(z.config.Zones))
+	for _, zone := range z.config.Zones {
+		config := z.config.zoneConfig(zone)
+		client, ok := z.clients[zone.Name]
+		if !ok {
+			client = z.client
+		}
+
+		zd := old[zone.Name]
+		if zd == nil {
+			zd = NewDetector(config, z.arp, client, z.clock).(*detector)
+			zd.zone = zone.Name
+			zd.overrides, zd.guestMode, zd.subs = z.overrides, z.guestMode, z.subs
+		} else {
+			zd.Config(config)
+			zd.arp, zd.client = z.arp, client
+		}
+		zd.history = z.history
+		zones = append(zones, zd)
+	}
+
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.zones = zones
+}
+
+// Status returns the status of the household with those of its zones as of
+// the last detection. It is safe to call concurrently with Detect.
+func (z *zonedDetector) Status() *Status {
+	z.mu.RLock()
+	defer z.mu.RUnlock()
+
+	status := z.detector.Status()
+	if len(z.zones) == 0 {
+		return status
+	}
+	s := *status
+	s.Zones = make([]*Status, 0, len(z.zones))
+	for _, zd := range z.zones {
+		s.Zones = append(s.Zones, zd.Status())
+	}
+	return &s
+}
+
+// validateZones checks that the zones have unique names and valid
+// interfaces, devices, events, heartbeats and flapping detection, putting
+// the MAC addresses of their devices in canonical form.
+func (c *Config) validateZones(ctx context.Context, wNet wrap.Net) error {
+	names := make(map[string]bool, len(c.Zones))
+	for i, z := range c.Zones {
+		switch {
+		case z.Name == "":
+			return &elementError{i, fmt.Errorf("zone with no name")}
+		case !zoneName.MatchString(z.Name):
+			return &elementError{i, fmt.Errorf("invalid zone name: %#v", z.Name)}
+		case names[z.Name]:
+			return &elementError{i, fmt.Errorf("duplicate zone (%v)", z.Name)}
+		}
+		names[z.Name] = true
+
+		if err := z.validate(ctx, wNet); err != nil {
+			return &elementError{i, fmt.Errorf("zone %v: %w", z.Name, err)}
+		}
+	}
+	return nil
+}
+
+func (z Zone) validate(ctx context.Context, wNet wrap.Net) error {
+	if len(z.Interfaces) == 0 {
+		return fmt.Errorf("no interfaces")
+	}
+	if err := validateInterfaces(ctx, z.Interfaces, wNet); err != nil {
+		return err
+	}
+
+	if len(z.MACAddresses) == 0 {
+		return fmt.Errorf("no MAC addresses")
+	}
+	if err := normalizeDevices(z.MACAddresses); err != nil {
+		return err
+	}
+
+	events := z.IFTTT.Events
+	for _, e := range []struct {
+		kind  string
+		event Event
+	}{{"present", events.Present}, {"absent", events.Absent}, {"flapping", events.Flapping}, {"heartbeat", events.Heartbeat}} {
+		if err := e.event.validate(e.kind); err != nil {
+			return err
+		}
+	}
+
+	if z.Heartbeat.Every < 0 {
+		return fmt.Errorf("negative heartbeat every (%v)", z.Heartbeat.Every)
+	}
+	return z.Flapping.validate()
+}
+
+// interfaceNames returns the names of the interfaces of the zone.
+func (z Zone) interfaceNames() []string {
+	names := make([]string, 0, len(z.Interfaces))
+	for _, i := range z.Interfaces {
+		names = append(names, i.Name)
+	}
+	return names
+}
+
+// addresses returns the MAC addresses of the devices of the zone.
+func (z Zone) addresses() []string {
+	addresses := make([]string, 0, len(z.MACAddresses))
+	for _, d := range z.MACAddresses {
+		addresses = append(addresses, d.MACAddress)
+	}
+	return addresses
+}
+
+// ZoneConfig returns the config of the validated zone named name, which is
+// the config with the interfaces, devices, IFTTT events, heartbeat and
+// flapping detection of the zone and no zones, or nil when there is no such
+// zone.
+func (c *Config) ZoneConfig(name string) *Config {
+	for _, z := range c.Zones {
+		if z.Name == name {
+			return c.zoneConfig(z)
+		}
+	}
+	return nil
+}
+
+func (c *Config) zoneConfig(z Zone) *Config {
+	zc := *c
+	zc.Interfaces, zc.MACAddresses, zc.Zones = z.Interfaces, z.MACAddresses, nil
+	zc.IFTTT.Events = z.IFTTT.Events.inherit(c.IFTTT.Events)
+	if z.Heartbeat != (Heartbeat{}) {
+		zc.Heartbeat, zc.RetriggerAfter = z.Heartbeat, 0
+	}
+	if z.Flapping != (Flapping{}) {
+		zc.Flapping = z.Flapping
+	}
+	return &zc
+}
+
+// inherit returns the events with those which are unset replaced by the
+// parent's and the parent's event names for those which have none.
+func (e Events) inherit(parent Events) Events {
+	return Events{
+		Present:   e.Present.inherit(parent.Present),
+		Absent:    e.Absent.inherit(parent.Absent),
+		Flapping:  e.Flapping.inherit(parent.Flapping),
+		Heartbeat: e.Heartbeat.inherit(parent.Heartbeat),
+	}
+}
+
+func (e Event) inherit(parent Event) Event {
+	if e == (Event{}) {
+		return parent
+	}
+	if e.Event == "" {
+		e.Event = parent.Event
+	}
+	return e
+}
@@ -0,0 +1,228 @@
+package presence
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"goa.design/clue/log"
+
+	"douglasthrift.net/presence/ifttt"
+	mockifttt "douglasthrift.net/presence/ifttt/mocks"
+	"douglasthrift.net/presence/neighbors"
+	mockneighbors "douglasthrift.net/presence/neighbors/mocks"
+	"douglasthrift.net/presence/wrap"
+)
+
+func TestConfig_ZoneConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &Config{
+		Interval:       time.Minute,
+		RetriggerAfter: time.Hour,
+		Flapping:       Flapping{Transitions: 4, Window: 10 * time.Minute},
+		Interfaces:     []Interface{{Name: "eth0.10"}},
+		MACAddresses:   []Device{{MACAddress: "00:00:00:00:00:01"}},
+		IFTTT: IFTTT{
+			Key: "abcdef123456",
+			Events: Events{
+				Present:  Event{Event: defaultPresentEvent, Value1: "{{.Hostname}}"},
+				Absent:   Event{Event: defaultAbsentEvent},
+				Flapping: Event{Event: "flapping"},
+			},
+		},
+		Zones: []Zone{
+			{
+				Name:         "rental",
+				Interfaces:   []Interface{{Name: "eth0.20"}},
+				MACAddresses: []Device{{MACAddress: "00:00:00:00:00:21"}},
+				IFTTT: ZoneIFTTT{
+					Events: Events{
+						Present: Event{Event: "rental_present"},
+						Absent:  Event{Value1: "{{.Zone}}"},
+					},
+				},
+				Heartbeat: Heartbeat{Every: 2 * time.Hour},
+			},
+		},
+	}
+
+	assert.Nil(config.ZoneConfig("garage"))
+	assert.Equal(&Config{
+		Interval:     time.Minute,
+		Heartbeat:    Heartbeat{Every: 2 * time.Hour},
+		Flapping:     Flapping{Transitions: 4, Window: 10 * time.Minute},
+		Interfaces:   []Interface{{Name: "eth0.20"}},
+		MACAddresses: []Device{{MACAddress: "00:00:00:00:00:21"}},
+		IFTTT: IFTTT{
+			Key: "abcdef123456",
+			Events: Events{
+				Present:  Event{Event: "rental_present"},
+				Absent:   Event{Event: defaultAbsentEvent, Value1: "{{.Zone}}"},
+				Flapping: Event{Event: "flapping"},
+			},
+		},
+	}, config.ZoneConfig("rental"))
+}
+
+func TestZonedDetector(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const (
+		mac1 = "00:00:00:00:00:01"
+		mac2 = "00:00:00:00:00:21"
+	)
+
+	var (
+		arp          = mockneighbors.NewARP(t)
+		client       = mockifttt.NewClient(t)
+		rentalClient = mockifttt.NewClient(t)
+		config       = &Config{
+			Interfaces:   []Interface{{Name: "eth0.10"}},
+			MACAddresses: []Device{{MACAddress: mac1, Owner: "Alice"}},
+			Zones: []Zone{{
+				Name:         "rental",
+				Interfaces:   []Interface{{Name: "eth0.20"}},
+				MACAddresses: []Device{{MACAddress: mac2, Owner: "Carol"}},
+			}},
+		}
+		d = newZonedDetector(&Runtime{
+			Config:      config,
+			ARP:         arp,
+			Client:      client,
+			ZoneClients: map[string]ifttt.Client{"rental": rentalClient},
+		}, wrap.NewFakeClock(start))
+		detect = func(present1, present2 bool) {
+			var detected context.Context
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				assert.Equal("eth0.10", ifs[0].Name)
+				assert.Len(addrStates, 1)
+				detected = ctx
+				addrStates[mac1].Set(present1)
+				state.Set(present1)
+				return nil
+			})
+			arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+				assert.Equal("eth0.20", ifs[0].Name)
+				assert.Len(addrStates, 1)
+				assert.True(ctx == detected, "neighbor table not shared")
+				addrStates[mac2].Set(present2)
+				state.Set(present2)
+				return nil
+			})
+			assert.NoError(d.Detect(ctx))
+		}
+		household = func(c <-chan Change) (changes []Change) {
+			for {
+				select {
+				case change := <-c:
+					if change.Household() {
+						changes = append(changes, change)
+					}
+				default:
+					return
+				}
+			}
+		}
+	)
+	c := d.Subscribe(ctx)
+
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.True(data.Present)
+		assert.Empty(data.Zone)
+		return "present", &ifttt.Values{}, nil
+	})
+	rentalClient.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.False(data.Present)
+		assert.Equal("rental", data.Zone)
+		return "absent", &ifttt.Values{}, nil
+	})
+	detect(true, false)
+	assert.Equal([]Change{
+		{Time: start, Was: false, Present: true, Source: ChangeSourceDetection},
+		{Time: start, Was: true, Present: false, Source: ChangeSourceDetection, Zone: "rental"},
+	}, household(c))
+
+	status := d.Status()
+	assert.True(status.Present)
+	assert.Empty(status.Zone)
+	if assert.Len(status.Zones, 1) {
+		assert.Equal("rental", status.Zones[0].Zone)
+		assert.False(status.Zones[0].Present)
+		assert.Equal(mac2, status.Zones[0].Devices[0].MACAddress)
+	}
+
+	// Overrides apply to the devices of zones too.
+	assert.NoError(d.SetOverride(StateOverride{Target: "Carol", Present: true, Created: start}))
+	rentalClient.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.True(data.Present)
+		return "present", &ifttt.Values{}, nil
+	})
+	detect(true, false)
+	assert.Equal([]Change{{Time: start, Was: false, Present: true, Source: ChangeSourceOverride, Zone: "rental"}}, household(c))
+	assert.True(d.Status().Zones[0].Present)
+
+	// Zones which are removed are no longer detected.
+	d.Config(&Config{Interfaces: config.Interfaces, MACAddresses: config.MACAddresses})
+	arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		addrStates[mac1].Set(true)
+		state.Set(true)
+		return nil
+	})
+	assert.NoError(d.Detect(ctx))
+	assert.Empty(d.Status().Zones)
+
+	assert.False(arp.HasMore(), "missing expected arp calls")
+	assert.False(client.HasMore(), "missing expected client calls")
+	assert.False(rentalClient.HasMore(), "missing expected client calls")
+}
+
+func TestZonedDetector_OnlyZones(t *testing.T) {
+	ctx := log.Context(context.Background(), log.WithDebug())
+	assert := assert.New(t)
+
+	const mac = "00:00:00:00:00:21"
+
+	var (
+		arp    = mockneighbors.NewARP(t)
+		client = mockifttt.NewClient(t)
+		d      = newZonedDetector(&Runtime{
+			Config: &Config{
+				Interfaces: []Interface{{Name: "*"}},
+				Zones: []Zone{{
+					Name:         "rental",
+					Interfaces:   []Interface{{Name: "eth0.20"}},
+					MACAddresses: []Device{{MACAddress: mac}},
+				}},
+			},
+			ARP:    arp,
+			Client: client,
+		}, wrap.NewFakeClock(start))
+	)
+
+	// Only the zone is detected.
+	arp.AddPresent(func(ctx context.Context, ifs neighbors.Interfaces, state neighbors.State, addrStates neighbors.HardwareAddrStates) error {
+		assert.Equal("eth0.20", ifs[0].Name)
+		addrStates[mac].Set(true)
+		state.Set(true)
+		return nil
+	})
+	client.AddTrigger(func(ctx context.Context, data *ifttt.Data) (string, *ifttt.Values, error) {
+		assert.True(data.Present)
+		assert.Equal("rental", data.Zone)
+		return "present", &ifttt.Values{}, nil
+	})
+	assert.NoError(d.Detect(ctx))
+
+	status := d.Status()
+	assert.False(status.Present)
+	assert.Empty(status.Devices)
+	if assert.Len(status.Zones, 1) {
+		assert.True(status.Zones[0].Present)
+	}
+
+	assert.False(arp.HasMore(), "missing expected arp calls")
+	assert.False(client.HasMore(), "missing expected client calls")
+}